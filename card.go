@@ -0,0 +1,284 @@
+package ft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CardBrand is the payment network of a card number
+type CardBrand string
+
+const (
+	CardBrandUnknown    CardBrand = ""
+	CardBrandVisa       CardBrand = "visa"
+	CardBrandMastercard CardBrand = "mastercard"
+	CardBrandAmex       CardBrand = "amex"
+	CardBrandDiscover   CardBrand = "discover"
+	CardBrandDiners     CardBrand = "diners"
+	CardBrandJCB        CardBrand = "jcb"
+	CardBrandUnionPay   CardBrand = "unionpay"
+	CardBrandMaestro    CardBrand = "maestro"
+)
+
+// cardPrefixes maps IIN prefix ranges to brands, see
+// https://en.wikipedia.org/wiki/Payment_card_number#Issuer_identification_number_(IIN)
+// More specific ranges must be listed first
+var cardPrefixes = []struct {
+	low, high int
+	digits    int
+	brand     CardBrand
+}{
+	{34, 34, 2, CardBrandAmex},
+	{37, 37, 2, CardBrandAmex},
+	{300, 305, 3, CardBrandDiners},
+	{36, 36, 2, CardBrandDiners},
+	{38, 39, 2, CardBrandDiners},
+	{3528, 3589, 4, CardBrandJCB},
+	{4, 4, 1, CardBrandVisa},
+	{51, 55, 2, CardBrandMastercard},
+	{2221, 2720, 4, CardBrandMastercard},
+	{6011, 6011, 4, CardBrandDiscover},
+	{644, 649, 3, CardBrandDiscover},
+	{65, 65, 2, CardBrandDiscover},
+	{62, 62, 2, CardBrandUnionPay},
+	{50, 50, 2, CardBrandMaestro},
+	{56, 69, 2, CardBrandMaestro},
+}
+
+// CardNumber can be used to decode a payment card number (PAN) from a
+// JSON string or number. Spaces and dashes are removed, and the length and
+// Luhn checksum are validated. The number is masked by MarshalJSON,
+// MarshalText, String and all fmt verbs. Call Reveal to read it.
+// The number is stored behind a pointer, so printers that read unexported
+// fields by reflection only see an address, see Secret
+type CardNumber struct {
+	number *string
+}
+
+// ParseCardNumber returns a CardNumber for the given string
+func ParseCardNumber(s string) (CardNumber, error) {
+	number := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, s)
+	if number == "" {
+		return CardNumber{}, errors.Errorf("card number is empty")
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return CardNumber{}, errors.Errorf(
+				"card number has invalid character %q", r)
+		}
+	}
+	if len(number) < 12 || len(number) > 19 {
+		return CardNumber{}, errors.Errorf(
+			"card number must have 12 to 19 digits, found %d", len(number))
+	}
+	if !luhn(number) {
+		return CardNumber{}, errors.Errorf("card number checksum is invalid")
+	}
+	return CardNumber{number: &number}, nil
+}
+
+// luhn returns true if the string of digits has a valid check digit
+func luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Reveal returns the full card number
+func (fc CardNumber) Reveal() string {
+	if fc.number == nil {
+		return ""
+	}
+	return *fc.number
+}
+
+// Brand returns the card brand detected from the number prefix
+func (fc CardNumber) Brand() CardBrand {
+	number := fc.Reveal()
+	for _, p := range cardPrefixes {
+		if len(number) < p.digits {
+			continue
+		}
+		prefix, err := strconv.Atoi(number[:p.digits])
+		if err != nil {
+			continue
+		}
+		if prefix >= p.low && prefix <= p.high {
+			return p.brand
+		}
+	}
+	return CardBrandUnknown
+}
+
+// Last4 returns the last four digits of the card number
+func (fc CardNumber) Last4() string {
+	number := fc.Reveal()
+	if len(number) < 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
+
+// Masked returns the card number with all but the first six
+// and last four digits replaced by "*"
+func (fc CardNumber) Masked() string {
+	number := fc.Reveal()
+	if len(number) <= 10 {
+		return strings.Repeat("*", len(number))
+	}
+	return number[:6] +
+		strings.Repeat("*", len(number)-10) +
+		number[len(number)-4:]
+}
+
+// String returns the masked card number
+func (fc CardNumber) String() string {
+	return fc.Masked()
+}
+
+// GoString returns the masked card number
+func (fc CardNumber) GoString() string {
+	return fc.Masked()
+}
+
+// Format implements fmt.Formatter, all verbs print the masked number
+func (fc CardNumber) Format(f fmt.State, verb rune) {
+	_, _ = f.Write([]byte(fc.Masked()))
+}
+
+// MarshalJSON method for CardNumber
+func (fc CardNumber) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fc.Masked())
+}
+
+// UnmarshalJSON method for CardNumber
+func (fc *CardNumber) UnmarshalJSON(bArr []byte) (err error) {
+	s := ""
+
+	// Value is null
+	if string(bArr) == "null" {
+		*fc = CardNumber{}
+		return
+	}
+
+	// Value is a...
+	// string
+	if err = json.Unmarshal(bArr, &s); err == nil {
+		c, err := ParseCardNumber(s)
+		if err != nil {
+			return err
+		}
+		*fc = c
+		return nil
+	}
+
+	// number, use the raw bytes to avoid precision loss.
+	// Signs, fractions and exponents are not allowed
+	if isJSONNumber(bArr) {
+		number := string(bytes.TrimSpace(bArr))
+		if strings.Trim(number, "0123456789") != "" {
+			return errors.Errorf("invalid card number %s", number)
+		}
+		c, err := ParseCardNumber(number)
+		if err != nil {
+			return err
+		}
+		*fc = c
+		return nil
+	}
+
+	return errors.Errorf("invalid card number %s", bArr)
+}
+
+func (fc CardNumber) MarshalText() (text []byte, err error) {
+	return []byte(fc.Masked()), nil
+}
+
+func (fc *CardNumber) UnmarshalText(text []byte) error {
+	c, err := ParseCardNumber(string(text))
+	if err != nil {
+		return err
+	}
+	*fc = c
+	return nil
+}
+
+// NCardNumber can be used to decode a payment card number that allows null,
+// see CardNumber
+type NCardNumber struct {
+	CardNumber
+	Valid bool
+}
+
+func NCardNumberFrom(c CardNumber) NCardNumber {
+	return NCardNumber{CardNumber: c, Valid: true}
+}
+
+// MarshalJSON method for NCardNumber
+func (fc NCardNumber) MarshalJSON() ([]byte, error) {
+	if !fc.Valid {
+		return []byte(`null`), nil
+	}
+	return fc.CardNumber.MarshalJSON()
+}
+
+// UnmarshalJSON method for NCardNumber
+func (fc *NCardNumber) UnmarshalJSON(bArr []byte) (err error) {
+	// Value is null
+	if string(bArr) == "null" {
+		*fc = NCardNumber{}
+		return
+	}
+
+	c := CardNumber{}
+	if err = c.UnmarshalJSON(bArr); err != nil {
+		return err
+	}
+	*fc = NCardNumberFrom(c)
+	return
+}
+
+func (fc NCardNumber) MarshalText() (text []byte, err error) {
+	if !fc.Valid {
+		return []byte{}, nil
+	}
+	return fc.CardNumber.MarshalText()
+}
+
+func (fc *NCardNumber) UnmarshalText(text []byte) error {
+	return fc.unmarshalText(text, &defaultPolicy)
+}
+
+func (fc *NCardNumber) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, true)
+	if ok, valid, err := tp.nullOrEmpty(
+		string(text), tp.StringKinds, defaultStringKinds, true); ok {
+		if err != nil {
+			return err
+		}
+		*fc = NCardNumber{Valid: valid}
+		return nil
+	}
+	return fc.UnmarshalJSON(bArr)
+}
@@ -0,0 +1,137 @@
+package ft_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestUnmarshalCardNumber(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Card ft.CardNumber `json:"card"`
+	}
+	d := Data{}
+
+	// null
+	b := []byte(`{"card": null}`)
+	err := json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("", d.Card.Reveal()) // Value must match
+
+	// string
+	b = []byte(`{"card": "4111 1111 1111 1111"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("4111111111111111", d.Card.Reveal()) // Value must match
+
+	b = []byte(`{"card": "3782-822463-10005"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("378282246310005", d.Card.Reveal()) // Value must match
+
+	b = []byte(`{"card": "4111 1111 1111 1112"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("card number checksum is invalid", err.Error())
+
+	b = []byte(`{"card": "4111"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("card number must have 12 to 19 digits, found 4", err.Error())
+
+	b = []byte(`{"card": "4111.1111.1111.1111"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("card number has invalid character '.'", err.Error())
+
+	b = []byte(`{"card": ""}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("card number is empty", err.Error())
+
+	// number, 19 digits
+	b = []byte(`{"card": 6200000000000000000}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("6200000000000000000", d.Card.Reveal()) // Value must match
+
+	b = []byte(`{"card": -4111111111111111}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("invalid card number -4111111111111111", err.Error())
+
+	b = []byte(`{"card": 4.111111111111111e15}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("invalid card number 4.111111111111111e15", err.Error())
+
+	// bool
+	b = []byte(`{"card": true}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("invalid card number true", err.Error())
+}
+
+func TestCardNumberBrand(t *testing.T) {
+	is := is.New(t)
+
+	for number, brand := range map[string]ft.CardBrand{
+		"4111111111111111":    ft.CardBrandVisa,
+		"5555555555554444":    ft.CardBrandMastercard,
+		"2223003122003222":    ft.CardBrandMastercard,
+		"378282246310005":     ft.CardBrandAmex,
+		"6011111111111117":    ft.CardBrandDiscover,
+		"3530111333300000":    ft.CardBrandJCB,
+		"30569309025904":      ft.CardBrandDiners,
+		"6200000000000000000": ft.CardBrandUnionPay,
+		"6759649826438453":    ft.CardBrandMaestro,
+		"9999999999999995":    ft.CardBrandUnknown,
+	} {
+		c, err := ft.ParseCardNumber(number)
+		is.NoErr(err)
+		is.Equal(brand, c.Brand()) // Brand must match
+	}
+}
+
+func TestMaskCardNumber(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Card  ft.CardNumber  `json:"card"`
+		NCard ft.NCardNumber `json:"ncard"`
+	}
+	c, err := ft.ParseCardNumber("4111111111111111")
+	is.NoErr(err)
+	is.Equal("411111******1111", c.Masked())
+	is.Equal("1111", c.Last4())
+
+	d := Data{Card: c}
+	b, err := json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"card":"411111******1111","ncard":null}`, string(b))
+
+	d.NCard = ft.NCardNumberFrom(c)
+	b, err = json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"card":"411111******1111","ncard":"411111******1111"}`,
+		string(b))
+
+	// fmt verbs
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%d"} {
+		s := fmt.Sprintf(verb, d)
+		is.True(!strings.Contains(s, "4111111111111111")) // Must mask
+	}
+	is.Equal("411111******1111", c.String())
+
+	// Unexported fields are printed by reflection, without calling Format
+	type payment struct {
+		card  ft.CardNumber
+		ncard ft.NCardNumber
+	}
+	p := payment{card: c, ncard: ft.NCardNumberFrom(c)}
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		s := fmt.Sprintf(verb, p)
+		is.True(!strings.Contains(s, "4111111111111111")) // Must not leak
+		s = fmt.Sprintf(verb, &p)
+		is.True(!strings.Contains(s, "4111111111111111")) // Must not leak
+	}
+}
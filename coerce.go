@@ -0,0 +1,559 @@
+package ft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Coercion rules shared by the types with and without the N-prefix.
+// The valid return value is false if the JSON value is null,
+// or a string that is decoded as null, nullable is set for N-types
+
+var (
+	// ErrOverflow is the NumberError cause for values out of range
+	ErrOverflow = errors.New("value out of range")
+	// ErrPrecision is the NumberError cause for values
+	// that can not be represented exactly
+	ErrPrecision = errors.New("value loses precision")
+)
+
+// NumberError is returned for numbers that can not be decoded to the
+// Go type without corrupting the value, use errors.Is to check the cause
+type NumberError struct {
+	// Value is the number as it appears in the input
+	Value string
+	// Type is the Go type, e.g. int64 or float64
+	Type string
+	// Err is ErrOverflow or ErrPrecision
+	Err error
+}
+
+func (e *NumberError) Error() string {
+	if e.Err == ErrPrecision {
+		return fmt.Sprintf(
+			"value %s can not be represented exactly as %s", e.Value, e.Type)
+	}
+	return fmt.Sprintf("value %s overflows %s", e.Value, e.Type)
+}
+
+func (e *NumberError) Unwrap() error {
+	return e.Err
+}
+
+// coerceString coerces any JSON scalar to string,
+// objects and arrays are coerced as per the StringComposite policy.
+// Arrays are only unwrapped if StringComposite is StringCompositeError
+func coerceString(bArr []byte, p *Policy, nullable bool) (
+	s string, valid bool, err error) {
+
+	if p.StringComposite == StringCompositeError {
+		if bArr, err = p.unwrap(bArr); err != nil {
+			return s, false, err
+		}
+	}
+	kind := kindOf(bArr)
+	defaultKinds := defaultStringKinds
+	if p.StringComposite != StringCompositeError {
+		defaultKinds |= KindObject | KindArray
+	}
+	if err = p.accept(kind, p.StringKinds, defaultKinds, nullable); err != nil {
+		if kind == KindObject || kind == KindArray {
+			return s, false, errors.Errorf("cannot convert %s to string", kind)
+		}
+		return s, false, err
+	}
+
+	switch kind {
+	// Value is null
+	case KindNull:
+		return s, false, nil
+
+	// Value is a...
+	// string
+	case KindString:
+		if s, err = p.unquote(bArr); err != nil {
+			return s, false, err
+		}
+		s = p.normalise(s)
+		if ok, valid, err := p.nullOrEmpty(
+			s, p.StringKinds, defaultStringKinds, nullable); ok {
+			return "", valid, err
+		}
+		return s, true, nil
+
+	// number, verbatim so precision is not lost
+	case KindNumber:
+		n := json.Number("")
+		if err = json.Unmarshal(bArr, &n); err != nil {
+			return s, false, err
+		}
+		return n.String(), true, nil
+
+	// bool
+	case KindBool:
+		b := false
+		if err = json.Unmarshal(bArr, &b); err != nil {
+			return s, false, err
+		}
+		return string(bArr), true, nil
+
+	// object or array
+	case KindObject, KindArray:
+		if s, err = p.compositeString(bArr, kind); err != nil {
+			return s, false, err
+		}
+		return s, true, nil
+	}
+
+	return s, false, kindError(kind)
+}
+
+// compositeString coerces objects and arrays as per the
+// StringComposite policy
+func (p *Policy) compositeString(bArr []byte, kind Kind) (string, error) {
+	switch {
+	case p.StringComposite == StringCompositeRaw:
+		buf := bytes.Buffer{}
+		if err := json.Compact(&buf, bArr); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+
+	case p.StringComposite == StringCompositeJoin && kind == KindArray:
+		elements := []json.RawMessage{}
+		if err := json.Unmarshal(bArr, &elements); err != nil {
+			return "", err
+		}
+		parts := make([]string, len(elements))
+		for i, element := range elements {
+			s, _, err := coerceString(element, p, false)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		sep := p.JoinSeparator
+		if sep == "" {
+			sep = ","
+		}
+		return strings.Join(parts, sep), nil
+	}
+
+	return "", errors.Errorf("cannot convert %s to string", kind)
+}
+
+// coerceInt coerces strings and numbers to int64,
+// numbers with a fractional part are converted as per the FloatToInt policy
+func coerceInt(bArr []byte, p *Policy, nullable bool) (
+	i int64, valid bool, err error) {
+
+	if bArr, err = p.unwrap(bArr); err != nil {
+		return i, false, err
+	}
+	kind := kindOf(bArr)
+	if err = p.accept(kind, p.IntKinds, defaultIntKinds, nullable); err != nil {
+		return i, false, err
+	}
+
+	switch kind {
+	// Value is null
+	case KindNull:
+		return i, false, nil
+
+	// Value is a...
+	// string
+	case KindString:
+		s, err := p.unquote(bArr)
+		if err != nil {
+			return i, false, err
+		}
+		if ok, valid, err := p.nullOrEmpty(
+			s, p.IntKinds, defaultIntKinds, nullable); ok {
+			return i, valid, err
+		}
+		if p.emptyNull(s, nullable) {
+			return i, false, nil
+		}
+		if s, err = p.numeric(s, "ParseInt"); err != nil {
+			return i, false, err
+		}
+		i, err = p.parseInt(s)
+		if err != nil {
+			return i, false, err
+		}
+		return i, true, nil
+
+	// number
+	case KindNumber:
+		n := json.Number("")
+		if err = json.Unmarshal(bArr, &n); err != nil {
+			return i, false, err
+		}
+		i, err = p.parseInt(n.String())
+		if err != nil {
+			return i, false, err
+		}
+		return i, true, nil
+
+	// bool
+	case KindBool:
+		b := false
+		if err = json.Unmarshal(bArr, &b); err != nil {
+			return i, false, err
+		}
+		if b {
+			return 1, true, nil
+		}
+		return 0, true, nil
+	}
+
+	return i, false, kindError(kind)
+}
+
+// unwrap returns the element of a single element array,
+// if the UnwrapArrays policy is set
+func (p *Policy) unwrap(bArr []byte) ([]byte, error) {
+	if !p.UnwrapArrays || kindOf(bArr) != KindArray {
+		return bArr, nil
+	}
+	elements := []json.RawMessage{}
+	if err := json.Unmarshal(bArr, &elements); err != nil {
+		return bArr, err
+	}
+	if len(elements) != 1 {
+		return bArr, errors.Errorf(
+			"array must have 1 element, found %d", len(elements))
+	}
+	return elements[0], nil
+}
+
+// nullOrEmpty applies the NullStrings and EmptyString policy to s.
+// If ok is true the zero value is returned, with valid and err
+func (p *Policy) nullOrEmpty(s string, kinds, defaultKinds Kind, nullable bool) (
+	ok bool, valid bool, err error) {
+
+	if nullable && containsFold(p.NullStrings, s) {
+		return true, false, p.accept(KindNull, kinds, defaultKinds, nullable)
+	}
+	if p.EmptyString == EmptyStringKeep || strings.TrimSpace(s) != "" {
+		return false, false, nil
+	}
+	switch p.EmptyString {
+	case EmptyStringNull:
+		return true, false, p.accept(KindNull, kinds, defaultKinds, nullable)
+	case EmptyStringZero:
+		return true, true, nil
+	}
+	return true, false, errors.Errorf("value is an empty string")
+}
+
+// unquote returns the JSON string value, trimmed if the policy requires it
+func (p *Policy) unquote(bArr []byte) (s string, err error) {
+	if err = json.Unmarshal(bArr, &s); err != nil {
+		return s, err
+	}
+	if p.Trim {
+		s = strings.TrimSpace(s)
+	}
+	return s, nil
+}
+
+// parseInt parses a base 10 integer. Numbers with a decimal point or
+// exponent are converted as per the FloatToInt policy
+func (p *Policy) parseInt(s string) (int64, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return i, nil
+	}
+	if p.Radix && isRadix(s) {
+		i, err = parseRadix(s)
+		if errors.Is(err, strconv.ErrRange) {
+			return p.intOverflow(s)
+		}
+		return i, err
+	}
+	f, fErr := strconv.ParseFloat(s, 64)
+	if errors.Is(err, strconv.ErrRange) ||
+		(math.IsInf(f, 0) && errors.Is(fErr, strconv.ErrRange)) {
+		return p.intOverflow(s)
+	}
+	if fErr != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		// Not a number, return the ParseInt error
+		return 0, err
+	}
+	if p.FloatToInt == FloatToIntStrict {
+		return 0, errors.Errorf("value %s is not an integer", s)
+	}
+	d, dErr := decimal(s)
+	if dErr != nil {
+		// Hex floats and underscores are not decimal numbers
+		return 0, err
+	}
+	return p.decimalToInt(s, d)
+}
+
+// decimalToInt converts d, parsed from s, with exact arithmetic as per the
+// FloatToInt and Overflow policy. A float64 can not hold every fraction,
+// e.g. 2.4999999999999999 is 2.5 as a float64, nor every integer beyond
+// 2^53, e.g. 9223372036854775807.0 is 2^63 as a float64
+func (p *Policy) decimalToInt(s string, d *big.Float) (int64, error) {
+	i, acc := d.Int(nil)
+	if acc != big.Exact {
+		// i is truncated towards zero
+		switch p.FloatToInt {
+		case FloatToIntReject:
+			return 0, errors.Errorf("value %s has a fractional part", s)
+		case FloatToIntFloor:
+			if d.Sign() < 0 {
+				i.Sub(i, big.NewInt(1))
+			}
+		case FloatToIntCeil:
+			if d.Sign() > 0 {
+				i.Add(i, big.NewInt(1))
+			}
+		case FloatToIntHalfUp, FloatToIntHalfEven:
+			frac := new(big.Float).Sub(d, new(big.Float).SetInt(i))
+			half := frac.Abs(frac).Cmp(big.NewFloat(0.5))
+			if half > 0 || (half == 0 &&
+				(p.FloatToInt == FloatToIntHalfUp || i.Bit(0) == 1)) {
+				i.Add(i, big.NewInt(int64(d.Sign())))
+			}
+		}
+	}
+	if !i.IsInt64() {
+		return p.intOverflow(s)
+	}
+	return i.Int64(), nil
+}
+
+// intOverflow is used for integers out of range,
+// the bound of int64 is returned as per the Overflow policy
+func (p *Policy) intOverflow(s string) (int64, error) {
+	if p.Overflow == OverflowSaturate {
+		if strings.HasPrefix(s, "-") {
+			return math.MinInt64, nil
+		}
+		return math.MaxInt64, nil
+	}
+	return 0, &NumberError{Value: s, Type: "int64", Err: ErrOverflow}
+}
+
+// isRadix returns true if s has a base prefix, or underscores
+func isRadix(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	if len(s) > 1 && s[0] == '0' && strings.ContainsRune("xXoObB", rune(s[1])) {
+		return true
+	}
+	return strings.Contains(s, "_")
+}
+
+// parseRadix parses integers with the base prefixes 0x, 0o and 0b.
+// Underscores may separate digits, e.g. 1_000_000 or 0xFF_FF
+func parseRadix(s string) (int64, error) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(digits) > 1 && digits[0] == '0' &&
+		strings.ContainsRune("xXoObB", rune(digits[1])) {
+		return strconv.ParseInt(s, 0, 64)
+	}
+	// Base 0 parses a leading zero as octal, "0_17" must be decimal
+	if strings.HasPrefix(digits, "_") || strings.HasSuffix(digits, "_") ||
+		strings.Contains(digits, "__") {
+		return 0, &strconv.NumError{
+			Func: "ParseInt", Num: s, Err: strconv.ErrSyntax}
+	}
+	return strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, 64)
+}
+
+// maxExactFloat is 2^53, float64 can represent all integers up to it
+const maxExactFloat = 1 << 53
+
+// parseFloat parses a floating point number as per the Overflow policy.
+// Integers that can not be represented exactly, and non-zero numbers
+// that underflow to zero, return an error
+func (p *Policy) parseFloat(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if p.Overflow == OverflowSaturate {
+			if f < 0 {
+				return -math.MaxFloat64, nil
+			}
+			return math.MaxFloat64, nil
+		}
+		return 0, &NumberError{Value: s, Type: "float64", Err: ErrOverflow}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !p.AllowPrecisionLoss && lossy(s, f) {
+		return 0, &NumberError{Value: s, Type: "float64", Err: ErrPrecision}
+	}
+	return f, nil
+}
+
+// lossy returns true if s is an integer that f does not represent exactly,
+// e.g. 9007199254740993.0, or a non-zero number that f rounds to zero
+func lossy(s string, f float64) bool {
+	if (f != 0 && math.Abs(f) < maxExactFloat) ||
+		math.IsInf(f, 0) || math.IsNaN(f) {
+		return false
+	}
+	d, err := decimal(s)
+	if err != nil {
+		return false
+	}
+	if f == 0 {
+		return d.Sign() != 0
+	}
+	return d.IsInt() && d.Cmp(big.NewFloat(f)) != 0
+}
+
+// decimal parses the decimal number s with enough precision to hold
+// the digits of s, and every integer in the range of float64, exactly
+func decimal(s string) (*big.Float, error) {
+	prec := uint(64 + 4*len(s))
+	if prec < 1024 {
+		prec = 1024
+	}
+	d, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	return d, err
+}
+
+// coerceFloat coerces strings and numbers to float64,
+// strings like "NaN" and "Infinity" are coerced as per the NonFinite policy
+func coerceFloat(bArr []byte, p *Policy, nullable bool) (
+	f float64, valid bool, err error) {
+
+	if bArr, err = p.unwrap(bArr); err != nil {
+		return f, false, err
+	}
+	kind := kindOf(bArr)
+	if err = p.accept(kind, p.FloatKinds, defaultFloatKinds, nullable); err != nil {
+		return f, false, err
+	}
+
+	switch kind {
+	// Value is null
+	case KindNull:
+		return f, false, nil
+
+	// Value is a...
+	// string
+	case KindString:
+		s, err := p.unquote(bArr)
+		if err != nil {
+			return f, false, err
+		}
+		if ok, valid, err := p.nullOrEmpty(
+			s, p.FloatKinds, defaultFloatKinds, nullable); ok {
+			return f, valid, err
+		}
+		if p.emptyNull(s, nullable) {
+			return f, false, nil
+		}
+		if s, err = p.numeric(s, "ParseFloat"); err != nil {
+			return f, false, err
+		}
+		f, err = p.parseFloat(s)
+		if err != nil {
+			return f, false, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			switch p.NonFinite {
+			case NonFiniteReject:
+				return 0, false, errors.Errorf(
+					"value %q is not a finite number", s)
+			case NonFiniteNull:
+				err = p.accept(KindNull, p.FloatKinds, defaultFloatKinds, nullable)
+				return 0, false, err
+			}
+		}
+		return f, true, nil
+
+	// number
+	case KindNumber:
+		n := json.Number("")
+		if err = json.Unmarshal(bArr, &n); err != nil {
+			return f, false, err
+		}
+		f, err = p.parseFloat(n.String())
+		if err != nil {
+			return f, false, err
+		}
+		return f, true, nil
+
+	// bool
+	case KindBool:
+		b := false
+		if err = json.Unmarshal(bArr, &b); err != nil {
+			return f, false, err
+		}
+		if b {
+			return 1, true, nil
+		}
+		return 0, true, nil
+	}
+
+	return f, false, kindError(kind)
+}
+
+// coerceBool coerces any JSON scalar to bool.
+// Strings are coerced as per the BoolStrings policy.
+// Numbers equal to 0 will evaluate to false,
+// all other numbers are true.
+func coerceBool(bArr []byte, p *Policy, nullable bool) (
+	b bool, valid bool, err error) {
+
+	if bArr, err = p.unwrap(bArr); err != nil {
+		return b, false, err
+	}
+	kind := kindOf(bArr)
+	if err = p.accept(kind, p.BoolKinds, defaultBoolKinds, nullable); err != nil {
+		return b, false, err
+	}
+
+	switch kind {
+	// Value is null
+	case KindNull:
+		return b, false, nil
+
+	// Value is a...
+	// string
+	case KindString:
+		s, err := p.unquote(bArr)
+		if err != nil {
+			return b, false, err
+		}
+		if ok, valid, err := p.nullOrEmpty(
+			s, p.BoolKinds, defaultBoolKinds, nullable); ok {
+			return b, valid, err
+		}
+		if b, err = p.parseBool(s); err != nil {
+			return b, false, err
+		}
+		return b, true, nil
+
+	// number
+	case KindNumber:
+		f := float64(0)
+		if err = json.Unmarshal(bArr, &f); err != nil {
+			return b, false, err
+		}
+		return f != 0, true, nil
+
+	// bool
+	case KindBool:
+		if err = json.Unmarshal(bArr, &b); err != nil {
+			return b, false, err
+		}
+		return b, true, nil
+	}
+
+	return b, false, kindError(kind)
+}
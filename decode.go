@@ -0,0 +1,834 @@
+package ft
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// policyUnmarshaler is implemented by types that coerce JSON values
+// as per a Policy. UnmarshalJSON uses the default policy
+type policyUnmarshaler interface {
+	unmarshalJSON(bArr []byte, p *Policy) error
+}
+
+// Decoder reads and decodes JSON values from an input stream,
+// like json.Decoder. Values of ft types are coerced as per the Policy
+type Decoder struct {
+	dec     *json.Decoder
+	policy  Policy
+	lenient *LenientReader
+}
+
+// NewDecoder returns a new decoder that reads from r,
+// it uses the default policy
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: json.NewDecoder(r)}
+}
+
+// SetPolicy sets the policy used by subsequent calls to Decode
+func (d *Decoder) SetPolicy(p Policy) {
+	d.policy = p
+}
+
+// More reports whether there is another element in the
+// current array or object being parsed
+func (d *Decoder) More() bool {
+	return d.dec.More()
+}
+
+// Decode reads the next JSON value from the input and stores it in v
+func (d *Decoder) Decode(v interface{}) error {
+	raw := json.RawMessage{}
+	if err := d.dec.Decode(&raw); err != nil {
+		if d.lenient != nil {
+			return d.lenient.inputError(err)
+		}
+		return err
+	}
+	p := d.policy
+	ds := newDecodeState()
+	err := ds.unmarshal(raw, v, &p)
+	if err != nil && d.lenient != nil && ds.errOffset >= 0 {
+		start := d.dec.InputOffset() - int64(len(raw))
+		return d.lenient.valueError(err, start+ds.errOffset)
+	}
+	return err
+}
+
+// Unmarshal parses the JSON data and stores the result in v,
+// like json.Unmarshal. Values of ft types are coerced as per the Policy.
+// The ",string" option of the json tag applies to fields of scalar Go
+// types like encoding/json, and is ignored for ft types
+func Unmarshal(data []byte, v interface{}, p Policy) error {
+	raw := json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return newDecodeState().unmarshal(raw, v, &p)
+}
+
+// decodeState walks the JSON value and the Go value in parallel
+type decodeState struct {
+	// index of the document that is decoded
+	index *jsonIndex
+	// path segments of the value that is decoded, e.g. ".items" and "[0]"
+	path []string
+	// errOffset is the offset in the document of the innermost value
+	// that returned an error, or -1
+	errOffset int64
+}
+
+// pathTo returns the JSON path of the key in the object that is decoded
+func (ds *decodeState) pathTo(key string) string {
+	return "$" + strings.Join(ds.path, "") + memberSegment(key)
+}
+
+// push adds a path segment, call the returned func to remove it
+func (ds *decodeState) push(segment string) func() {
+	ds.path = append(ds.path, segment)
+	return func() {
+		ds.path = ds.path[:len(ds.path)-1]
+	}
+}
+
+func newDecodeState() *decodeState {
+	return &decodeState{errOffset: -1}
+}
+
+func (ds *decodeState) unmarshal(bArr []byte, v interface{}, p *Policy) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+	ds.index = newJSONIndex(bArr)
+	if p.DuplicateKeys != DuplicateKeysAllow {
+		var err error
+		if bArr, err = ds.duplicates(bArr, p); err != nil {
+			return err
+		}
+	}
+	return ds.value(bArr, rv.Elem(), p)
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// value decodes bArr into rv, rv must be settable
+func (ds *decodeState) value(bArr []byte, rv reflect.Value, p *Policy) (err error) {
+	defer func() {
+		if err != nil && ds.errOffset < 0 {
+			ds.errOffset = int64(ds.offset(bArr))
+		}
+	}()
+	// Pointers are allocated as required, null sets them to nil
+	if rv.Kind() == reflect.Ptr {
+		if kindOf(bArr) == KindNull {
+			if p.RejectNull {
+				return kindError(KindNull)
+			}
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return ds.value(bArr, rv.Elem(), p)
+	}
+
+	switch u := rv.Addr().Interface().(type) {
+	case policyUnmarshaler:
+		return u.unmarshalJSON(bArr, p)
+	case json.Unmarshaler:
+		return u.UnmarshalJSON(bArr)
+	case encoding.TextUnmarshaler:
+		return json.Unmarshal(bArr, u)
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return ds.object(bArr, rv, p)
+	case reflect.Map:
+		return ds.mapValue(bArr, rv, p)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are base64 encoded
+			break
+		}
+		return ds.slice(bArr, rv, p)
+	case reflect.Array:
+		return ds.array(bArr, rv, p)
+	}
+
+	return json.Unmarshal(bArr, rv.Addr().Interface())
+}
+
+// typeError is returned if the JSON value does not match the Go type
+func typeError(kind Kind, t reflect.Type) error {
+	return &json.UnmarshalTypeError{Value: kind.String(), Type: t}
+}
+
+// object decodes a JSON object into a struct
+func (ds *decodeState) object(bArr []byte, rv reflect.Value, p *Policy) error {
+	kind := kindOf(bArr)
+	if kind == KindNull {
+		return nil
+	}
+	if kind != KindObject {
+		return typeError(kind, rv.Type())
+	}
+
+	fields, err := cachedFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	seen := make([]bool, len(fields.list))
+	err = ds.eachMember(bArr, func(key string, val []byte) error {
+		i, err := fields.lookup(key, p.KeyMatch)
+		if i < 0 || err != nil {
+			return err
+		}
+		if seen[i] {
+			// Keys that differ, e.g. by case or alias, match the same field
+			switch p.DuplicateKeys {
+			case DuplicateKeysError:
+				return errors.Errorf("duplicate key at %s", ds.pathTo(key))
+			case DuplicateKeysFirst:
+				return nil
+			case DuplicateKeysLast:
+				fv, err := fieldByIndex(rv, fields.list[i].index)
+				if err != nil {
+					return err
+				}
+				fv.Set(reflect.Zero(fv.Type()))
+			}
+		}
+		seen[i] = true
+		defer ds.push(memberSegment(key))()
+		return ds.field(val, rv, &fields.list[i], p)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Defaults for missing keys
+	for i := range fields.list {
+		if !seen[i] && fields.list[i].opts != nil &&
+			fields.list[i].opts.def != nil {
+			err = ds.field(fields.list[i].opts.def, rv, &fields.list[i], p)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// field decodes bArr into the struct field,
+// struct tag directives override the policy
+func (ds *decodeState) field(
+	bArr []byte, rv reflect.Value, f *field, p *Policy) error {
+
+	fv, err := fieldByIndex(rv, f.index)
+	if err != nil {
+		return err
+	}
+	if f.opts != nil && f.opts.def != nil && kindOf(bArr) == KindNull {
+		// Defaults are coerced as per the default policy
+		return ds.value(f.opts.def, fv, &defaultPolicy)
+	}
+	if f.quoted {
+		return quoted(bArr, fv)
+	}
+	if f.opts == nil {
+		return ds.value(bArr, fv, p)
+	}
+	fp := f.opts.apply(*p)
+	return ds.value(bArr, fv, &fp)
+}
+
+// quoted decodes bArr into a field with the ",string" option, i.e. a JSON
+// string that contains the value, or null. Like encoding/json the content
+// of the string must be a literal of the field type
+func quoted(bArr []byte, fv reflect.Value) error {
+	switch kindOf(bArr) {
+	case KindNull:
+		return json.Unmarshal(bArr, fv.Addr().Interface())
+	case KindString:
+		s := ""
+		if err := json.Unmarshal(bArr, &s); err != nil {
+			return err
+		}
+		t := fv.Type()
+		if t.Name() == "" && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		want := KindNumber
+		switch t.Kind() {
+		case reflect.Bool:
+			want = KindBool
+		case reflect.String:
+			want = KindString
+		}
+		if kind := kindOf([]byte(s)); !json.Valid([]byte(s)) ||
+			strings.Trim(s, jsonSpace) != s ||
+			(kind != want && kind != KindNull) {
+			return errors.Errorf("json: invalid use of ,string struct tag, "+
+				"trying to unmarshal %q into %v", s, fv.Type())
+		}
+		return json.Unmarshal([]byte(s), fv.Addr().Interface())
+	}
+	return errors.Errorf("json: invalid use of ,string struct tag, "+
+		"trying to unmarshal unquoted value into %v", fv.Type())
+}
+
+// mapValue decodes a JSON object into a map
+func (ds *decodeState) mapValue(bArr []byte, rv reflect.Value, p *Policy) error {
+	kind := kindOf(bArr)
+	if kind == KindNull {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	if kind != KindObject {
+		return typeError(kind, rv.Type())
+	}
+
+	t := rv.Type()
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(t))
+	}
+	return ds.eachMember(bArr, func(key string, val []byte) error {
+		kv, err := mapKey(key, t.Key(), p)
+		if err != nil {
+			return err
+		}
+		ev := reflect.New(t.Elem()).Elem()
+		defer ds.push(memberSegment(key))()
+		if err = ds.value(val, ev, p); err != nil {
+			return err
+		}
+		rv.SetMapIndex(kv, ev)
+		return nil
+	})
+}
+
+// mapKey converts the object key to the map key type,
+// keys of ft types are coerced as per the policy
+func mapKey(key string, kt reflect.Type, p *Policy) (reflect.Value, error) {
+	if reflect.PtrTo(kt).Implements(textUnmarshalerType) {
+		kv := reflect.New(kt)
+		var err error
+		switch u := kv.Interface().(type) {
+		case textPolicyUnmarshaler:
+			err = u.unmarshalText([]byte(key), p)
+		case encoding.TextUnmarshaler:
+			err = u.UnmarshalText([]byte(key))
+		}
+		return kv.Elem(), err
+	}
+
+	switch kt.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(key).Convert(kt), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, kt.Bits())
+		if err != nil {
+			return reflect.Value{}, typeError(KindNumber, kt)
+		}
+		return reflect.ValueOf(i).Convert(kt), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(key, 10, kt.Bits())
+		if err != nil {
+			return reflect.Value{}, typeError(KindNumber, kt)
+		}
+		return reflect.ValueOf(u).Convert(kt), nil
+	}
+	return reflect.Value{}, typeError(KindString, kt)
+}
+
+// slice decodes a JSON array into a slice
+func (ds *decodeState) slice(bArr []byte, rv reflect.Value, p *Policy) error {
+	kind := kindOf(bArr)
+	if kind == KindNull {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	if kind != KindArray {
+		return typeError(kind, rv.Type())
+	}
+
+	s := reflect.MakeSlice(rv.Type(), 0, 0)
+	err := ds.eachElement(bArr, func(i int, val []byte) error {
+		ev := reflect.New(rv.Type().Elem()).Elem()
+		defer ds.push("[" + strconv.Itoa(i) + "]")()
+		if err := ds.value(val, ev, p); err != nil {
+			return err
+		}
+		s = reflect.Append(s, ev)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	rv.Set(s)
+	return nil
+}
+
+// array decodes a JSON array into a Go array,
+// extra elements are ignored and missing elements are zeroed
+func (ds *decodeState) array(bArr []byte, rv reflect.Value, p *Policy) error {
+	kind := kindOf(bArr)
+	if kind == KindNull {
+		return nil
+	}
+	if kind != KindArray {
+		return typeError(kind, rv.Type())
+	}
+
+	n := 0
+	err := ds.eachElement(bArr, func(i int, val []byte) error {
+		n = i + 1
+		if i >= rv.Len() {
+			return nil
+		}
+		defer ds.push("[" + strconv.Itoa(i) + "]")()
+		return ds.value(val, rv.Index(i), p)
+	})
+	if err != nil {
+		return err
+	}
+	for i := n; i < rv.Len(); i++ {
+		rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+	}
+	return nil
+}
+
+// jsonIndex records where each object and array in a JSON document ends,
+// so members and elements are iterated without scanning nested values
+// again. Decoding time is linear in the size of the document,
+// instead of the size multiplied by the depth
+type jsonIndex struct {
+	data []byte
+	ends map[int]int
+}
+
+// newJSONIndex indexes data, that must be valid JSON
+func newJSONIndex(data []byte) *jsonIndex {
+	x := &jsonIndex{data: data, ends: map[int]int{}}
+	stack := []int{}
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '"':
+			i = stringEnd(data, i) - 1
+		case '{', '[':
+			stack = append(stack, i)
+		case '}', ']':
+			x.ends[stack[len(stack)-1]] = i + 1
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return x
+}
+
+// stringEnd returns the offset after the JSON string that starts at i
+func stringEnd(data []byte, i int) int {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// skipSpace returns the offset of the first byte from i
+// that is not JSON white space
+func (x *jsonIndex) skipSpace(i int) int {
+	for i < len(x.data) && strings.IndexByte(jsonSpace, x.data[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+// valueEnd returns the offset after the JSON value that starts at i
+func (x *jsonIndex) valueEnd(i int) int {
+	switch x.data[i] {
+	case '"':
+		return stringEnd(x.data, i)
+	case '{', '[':
+		return x.ends[i]
+	}
+	for i < len(x.data) && strings.IndexByte(",}] \t\n\r", x.data[i]) < 0 {
+		i++
+	}
+	return i
+}
+
+// members calls fn for each member of the object at offset i, in order.
+// Members start at the key and end after the value
+func (x *jsonIndex) members(
+	i int, fn func(start, end int, key string, val []byte) error) error {
+
+	data := x.data
+	i = x.skipSpace(i + 1)
+	for data[i] != '}' {
+		keyEnd := stringEnd(data, i)
+		key, err := unquoteKey(data[i:keyEnd])
+		if err != nil {
+			return err
+		}
+		v := x.skipSpace(x.skipSpace(keyEnd) + 1)
+		end := x.valueEnd(v)
+		if err = fn(i, end, key, data[v:end]); err != nil {
+			return err
+		}
+		i = x.skipSpace(end)
+		if data[i] == ',' {
+			i = x.skipSpace(i + 1)
+		}
+	}
+	return nil
+}
+
+// elements calls fn for each element of the array at offset i
+func (x *jsonIndex) elements(i int, fn func(n int, val []byte) error) error {
+	data := x.data
+	i = x.skipSpace(i + 1)
+	for n := 0; data[i] != ']'; n++ {
+		end := x.valueEnd(i)
+		if err := fn(n, data[i:end]); err != nil {
+			return err
+		}
+		i = x.skipSpace(end)
+		if data[i] == ',' {
+			i = x.skipSpace(i + 1)
+		}
+	}
+	return nil
+}
+
+// unquoteKey returns the value of the JSON string b
+func unquoteKey(b []byte) (key string, err error) {
+	raw := b[1 : len(b)-1]
+	if bytes.IndexByte(raw, '\\') < 0 && utf8.Valid(raw) {
+		return string(raw), nil
+	}
+	if err = json.Unmarshal(b, &key); err != nil {
+		return key, errors.WithStack(err)
+	}
+	return key, nil
+}
+
+// offset returns the offset of the value bArr in the document,
+// or -1 if it is not a sub-slice of the document
+func (ds *decodeState) offset(bArr []byte) int {
+	if x := ds.index; x != nil && len(bArr) > 0 {
+		// Sub-slices share the end of the backing array
+		off := cap(x.data) - cap(bArr)
+		if off >= 0 && off < len(x.data) && &x.data[off] == &bArr[0] {
+			return x.skipSpace(off)
+		}
+	}
+	return -1
+}
+
+// locate returns the index of bArr and the offset of the value in it.
+// Values are sub-slices of the document, values that are not,
+// e.g. struct tag defaults, are indexed separately
+func (ds *decodeState) locate(bArr []byte) (*jsonIndex, int, error) {
+	if off := ds.offset(bArr); off >= 0 {
+		return ds.index, off, nil
+	}
+	raw := json.RawMessage{}
+	if err := json.Unmarshal(bArr, &raw); err != nil {
+		return nil, 0, err
+	}
+	x := newJSONIndex(raw)
+	return x, x.skipSpace(0), nil
+}
+
+// eachMember calls fn for each member of the JSON object, in order
+func (ds *decodeState) eachMember(
+	bArr []byte, fn func(key string, val []byte) error) error {
+
+	x, i, err := ds.locate(bArr)
+	if err != nil {
+		return err
+	}
+	return x.members(i, func(_, _ int, key string, val []byte) error {
+		return fn(key, val)
+	})
+}
+
+// eachElement calls fn for each element of the JSON array
+func (ds *decodeState) eachElement(
+	bArr []byte, fn func(i int, val []byte) error) error {
+
+	x, i, err := ds.locate(bArr)
+	if err != nil {
+		return err
+	}
+	return x.elements(i, fn)
+}
+
+// field of a struct that can be decoded
+type field struct {
+	name   string
+	index  []int
+	tagged bool
+	// quoted is set by the ",string" option of the json tag,
+	// for fields of scalar Go types like encoding/json
+	quoted bool
+	opts   *tagOptions
+}
+
+// names returns the field name and aliases
+func (f *field) names() []string {
+	if f.opts == nil {
+		return []string{f.name}
+	}
+	return append([]string{f.name}, f.opts.aliases...)
+}
+
+// structFields of a type, in the order they are declared
+type structFields struct {
+	list   []field
+	byName map[string]int
+	// byStyle maps names and aliases with the key style removed,
+	// see keyStyle, to the fields
+	byStyle map[string][]int
+}
+
+// lookup returns the index of the field for the object key, or -1.
+// An exact match of the name or an alias is preferred,
+// otherwise keys are matched as per KeyMatch
+func (sf *structFields) lookup(key string, m KeyMatch) (int, error) {
+	if i, ok := sf.byName[key]; ok {
+		return i, nil
+	}
+	switch m {
+	case KeyMatchExact:
+		return -1, nil
+	case KeyMatchStyle:
+		matches := sf.byStyle[keyStyle(key)]
+		if len(matches) > 1 {
+			return -1, errors.Errorf("key %q matches fields %q and %q",
+				key, sf.list[matches[0]].name, sf.list[matches[1]].name)
+		}
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+		return -1, nil
+	}
+	for i := range sf.list {
+		for _, name := range sf.list[i].names() {
+			if strings.EqualFold(name, key) {
+				return i, nil
+			}
+		}
+	}
+	return -1, nil
+}
+
+// keyStyle returns key in lower case without separators,
+// e.g. "sku_id", "skuId" and "SKU-ID" are "skuid"
+func keyStyle(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', '.', ' ':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, key)
+}
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+// cachedFields returns the decodable fields of the struct type t
+func cachedFields(t reflect.Type) (*structFields, error) {
+	if sf, ok := fieldCache.Load(t); ok {
+		return sf.(*structFields), nil
+	}
+	sf, err := typeFields(t)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := fieldCache.LoadOrStore(t, sf)
+	return actual.(*structFields), nil
+}
+
+// typeFields follows the encoding/json rules for field names,
+// the ",string" option, and for fields promoted from embedded structs
+func typeFields(t reflect.Type) (*structFields, error) {
+	type candidate struct {
+		field
+		depth int
+	}
+	candidates := []candidate{}
+
+	var tagErr error
+	var walk func(t reflect.Type, index []int, depth int, visited map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, depth int, visited map[reflect.Type]bool) {
+		if visited[t] || tagErr != nil {
+			return
+		}
+		visited[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous {
+				if !sf.IsExported() && ft.Kind() != reflect.Struct {
+					continue
+				}
+			} else if !sf.IsExported() {
+				continue
+			}
+
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, options := tag, ""
+			if comma := strings.IndexByte(tag, ','); comma >= 0 {
+				name, options = tag[:comma], tag[comma:]
+			}
+
+			fieldIndex := append(append([]int{}, index...), i)
+			if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+				walk(ft, fieldIndex, depth+1, visited)
+				continue
+			}
+			tagged := name != ""
+			if !tagged {
+				name = sf.Name
+			}
+			opts, err := parseTag(sf.Tag.Get("ft"))
+			if err != nil {
+				tagErr = errors.Errorf(
+					"ft: invalid tag on field %s.%s: %s", t, sf.Name, err)
+				return
+			}
+			quoted := false
+			if strings.Contains(options+",", ",string,") {
+				// Pointers to scalars are quoted too, unless they are named
+				qt := sf.Type
+				if qt.Name() == "" && qt.Kind() == reflect.Ptr {
+					qt = qt.Elem()
+				}
+				switch qt.Kind() {
+				case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+					reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+					reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+					reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+					quoted = true
+				}
+			}
+			candidates = append(candidates, candidate{
+				field: field{name: name, index: fieldIndex, tagged: tagged,
+					quoted: quoted, opts: opts},
+				depth: depth,
+			})
+		}
+	}
+	walk(t, nil, 0, map[reflect.Type]bool{})
+	if tagErr != nil {
+		return nil, tagErr
+	}
+
+	// Shallower fields hide deeper ones, fields at the same depth
+	// conflict unless exactly one of them is tagged
+	sf := &structFields{byName: map[string]int{}}
+	for _, c := range candidates {
+		if _, ok := sf.byName[c.name]; ok {
+			continue
+		}
+		var dominant *candidate
+		conflict := false
+		for j := range candidates {
+			o := &candidates[j]
+			if o.name != c.name {
+				continue
+			}
+			switch {
+			case dominant == nil || o.depth < dominant.depth:
+				dominant, conflict = o, false
+			case o.depth == dominant.depth:
+				if o.tagged == dominant.tagged {
+					conflict = true
+				} else if o.tagged {
+					dominant, conflict = o, false
+				}
+			}
+		}
+		if conflict {
+			// Mark the name as seen so it is skipped
+			sf.byName[c.name] = -1
+			continue
+		}
+		sf.byName[c.name] = len(sf.list)
+		sf.list = append(sf.list, dominant.field)
+	}
+	for name, i := range sf.byName {
+		if i < 0 {
+			delete(sf.byName, name)
+		}
+	}
+
+	// Aliases must not be the name of another field
+	for i := range sf.list {
+		f := &sf.list[i]
+		for _, alias := range f.names()[1:] {
+			if j, ok := sf.byName[alias]; ok && j != i {
+				return nil, errors.Errorf(
+					"ft: alias %q of field %q in %s is the name of field %q",
+					alias, f.name, t, sf.list[j].name)
+			}
+			sf.byName[alias] = i
+		}
+	}
+	sf.byStyle = map[string][]int{}
+	for i := range sf.list {
+		for _, name := range sf.list[i].names() {
+			style := keyStyle(name)
+			matches := sf.byStyle[style]
+			if len(matches) == 0 || matches[len(matches)-1] != i {
+				sf.byStyle[style] = append(matches, i)
+			}
+		}
+	}
+	return sf, nil
+}
+
+// fieldByIndex returns the nested field,
+// nil pointers to embedded structs are allocated
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return rv, errors.Errorf(
+						"cannot set embedded pointer to unexported struct %v",
+						rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
@@ -0,0 +1,990 @@
+package ft_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestDecodeDefaultPolicy(t *testing.T) {
+	is := is.New(t)
+
+	type Embedded struct {
+		Name ft.String `json:"name"`
+	}
+	type Data struct {
+		Embedded
+		Secret  *ft.Secret          `json:"secret"`
+		Int     ft.Int              `json:"int"`
+		NFloat  ft.NFloat           `json:"nfloat"`
+		Bool    *ft.Bool            `json:"bool"`
+		Ints    []ft.Int            `json:"ints"`
+		Map     map[string]ft.NBool `json:"map"`
+		Array   [2]ft.String        `json:"array"`
+		Plain   string
+		Any     interface{} `json:"any"`
+		Ignored string      `json:"-"`
+		Count   int         `json:"count,string"`
+		Ratio   *float64    `json:"ratio,omitempty,string"`
+		Flag    bool        `json:",string"`
+		Label   string      `json:"label,string"`
+		Qty     ft.Int      `json:"qty,string"`
+	}
+	b := []byte(`{
+		"name": 123,
+		"secret": 456,
+		"INT": "7",
+		"nfloat": null,
+		"bool": "true",
+		"ints": [1, "2", 3.5],
+		"map": {"a": 1, "b": null},
+		"array": [true],
+		"plain": "foo",
+		"any": {"x": [1]},
+		"Ignored": "bar",
+		"unknown": 1,
+		"count": "12",
+		"ratio": "1.5",
+		"flag": "true",
+		"label": "\"x\"",
+		"qty": 3
+	}`)
+
+	// Must match encoding/json
+	expected := Data{}
+	err := json.Unmarshal(b, &expected)
+	is.NoErr(err)
+
+	d := Data{}
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(expected, d) // Value must match
+	is.Equal("123", d.Name.String)
+	is.Equal("456", d.Secret.Reveal())
+	is.Equal(int64(7), d.Int.Int64)
+	is.Equal(true, d.Bool.Bool)
+	is.Equal([]ft.Int{ft.IntFrom(1), ft.IntFrom(2), ft.IntFrom(3)}, d.Ints)
+	is.Equal(false, d.Map["b"].Valid)
+	is.Equal("", d.Ignored)
+	is.Equal(12, d.Count)   // Must be unquoted
+	is.Equal(1.5, *d.Ratio) // Must be unquoted
+	is.Equal(`x`, d.Label)  // Must be unquoted
+	is.Equal(int64(3), d.Qty.Int64)
+
+	// The ,string option errors like encoding/json
+	for _, input := range []string{
+		`{"count": 12}`, `{"count": "abc"}`, `{"count": "1.5"}`,
+		`{"count": true}`, `{"count": " 12"}`, `{"label": "x"}`, `{"flag": "1"}`,
+		`{"ratio": ["1"]}`,
+	} {
+		is.True(json.Unmarshal([]byte(input), &Data{}) != nil)
+		err = ft.Unmarshal([]byte(input), &Data{}, ft.Policy{})
+		is.True(err != nil) // Must error
+	}
+	err = ft.Unmarshal([]byte(`{"count": "abc"}`), &Data{}, ft.Policy{})
+	is.Equal(`json: invalid use of ,string struct tag, `+
+		`trying to unmarshal "abc" into int`, err.Error())
+	for _, input := range []string{
+		`{"ratio": null}`, `{"ratio": "null"}`, `{"count": "null"}`,
+	} {
+		expected, d = Data{}, Data{}
+		is.NoErr(json.Unmarshal([]byte(input), &expected))
+		is.NoErr(ft.Unmarshal([]byte(input), &d, ft.Policy{}))
+		is.Equal(expected, d) // Value must match
+	}
+
+	// Errors from ft types are not wrapped
+	err = ft.Unmarshal([]byte(`{"int": true}`), &d, ft.Policy{})
+	is.Equal("value is a bool", err.Error())
+
+	err = ft.Unmarshal([]byte(`{"int": `), &d, ft.Policy{})
+	is.Equal("unexpected end of JSON input", err.Error())
+
+	err = ft.Unmarshal([]byte(`{}`), d, ft.Policy{})
+	is.Equal("json: Unmarshal(non-pointer ft_test.Data)", err.Error())
+}
+
+func TestDecodePolicy(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		String ft.String  `json:"string"`
+		Int    ft.NInt    `json:"int"`
+		Float  ft.Float   `json:"float"`
+		Bool   ft.NBool   `json:"bool"`
+		Ranges []ft.Range `json:"ranges"`
+	}
+	d := Data{}
+
+	// Only strings are accepted
+	p := ft.Policy{StringKinds: ft.KindString}
+	err := ft.Unmarshal([]byte(`{"string": "123"}`), &d, p)
+	is.NoErr(err)
+	err = ft.Unmarshal([]byte(`{"string": 123}`), &d, p)
+	is.Equal("value is a number", err.Error())
+	err = ft.Unmarshal([]byte(`{"string": null}`), &d, p)
+	is.Equal("value is null", err.Error())
+
+	// Strings are not coerced to numbers, N-types always accept null
+	p = ft.Policy{IntKinds: ft.KindNumber, FloatKinds: ft.KindNumber}
+	err = ft.Unmarshal([]byte(`{"int": "1"}`), &d, p)
+	is.Equal("value is a string", err.Error())
+	err = ft.Unmarshal([]byte(`{"float": "1.5"}`), &d, p)
+	is.Equal("value is a string", err.Error())
+	err = ft.Unmarshal([]byte(`{"int": null}`), &d, p)
+	is.NoErr(err)
+	is.Equal(false, d.Int.Valid) // Must not be valid
+	err = ft.Unmarshal([]byte(`{"ranges": [[1, "2"]]}`), &d, p)
+	is.Equal("value is a string", err.Error())
+
+	// Bools are coerced to numbers
+	p = ft.Policy{IntKinds: ft.KindScalar}
+	err = ft.Unmarshal([]byte(`{"int": true}`), &d, p)
+	is.NoErr(err)
+	is.Equal(int64(1), d.Int.Int64) // Value must match
+
+	// Only bools are accepted
+	p = ft.Policy{BoolKinds: ft.KindBool}
+	err = ft.Unmarshal([]byte(`{"bool": "no"}`), &d, p)
+	is.Equal("value is a string", err.Error())
+	err = ft.Unmarshal([]byte(`{"bool": false}`), &d, p)
+	is.NoErr(err)
+	is.Equal(true, d.Bool.Valid) // Must be valid
+
+	// Fractions
+	p = ft.Policy{FloatToInt: ft.FloatToIntReject}
+	err = ft.Unmarshal([]byte(`{"int": 2.5}`), &d, p)
+	is.Equal("value 2.5 has a fractional part", err.Error())
+	err = ft.Unmarshal([]byte(`{"int": 3.0}`), &d, p)
+	is.NoErr(err)
+	is.Equal(int64(3), d.Int.Int64) // Value must match
+
+	// Objects are not accepted by default
+	err = ft.Unmarshal([]byte(`{"string": {}}`), &d, ft.Policy{})
+	is.Equal("cannot convert object to string", err.Error())
+}
+
+func TestDecoder(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Int ft.Int `json:"int"`
+	}
+
+	dec := ft.NewDecoder(strings.NewReader(`{"int": "1"} {"int": "2"}`))
+	d := Data{}
+	err := dec.Decode(&d)
+	is.NoErr(err)
+	is.Equal(int64(1), d.Int.Int64) // Value must match
+
+	dec.SetPolicy(ft.Policy{IntKinds: ft.KindNumber})
+	err = dec.Decode(&d)
+	is.Equal("value is a string", err.Error())
+
+	// Concurrent decoders with different policies don't interfere
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 200)
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			dec := ft.NewDecoder(bytes.NewReader([]byte(`{"int": "1"}`)))
+			errs <- dec.Decode(&Data{})
+		}()
+		go func() {
+			defer wg.Done()
+			dec := ft.NewDecoder(bytes.NewReader([]byte(`{"int": "1"}`)))
+			dec.SetPolicy(ft.Policy{IntKinds: ft.KindNumber})
+			if err := dec.Decode(&Data{}); err == nil {
+				errs <- errors.New("policy not applied")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		is.NoErr(err)
+	}
+}
+
+func TestDecodeBoolStrings(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Bool  ft.Bool  `json:"bool"`
+		NBool ft.NBool `json:"nbool"`
+		Flag  ft.Bool  `json:"flag" ft:"bools=permissive"`
+	}
+	d := Data{}
+
+	// Default vocabulary
+	for _, s := range []string{
+		"true", "1", "yes", "y", "on", "t", "enabled", "TRUE", " Yes "} {
+		err := ft.Unmarshal([]byte(`{"bool": "`+s+`"}`), &d, ft.Policy{})
+		is.NoErr(err)
+		is.Equal(true, d.Bool.Bool) // Value must match
+	}
+	for _, s := range []string{
+		"false", "0", "no", "n", "off", "f", "disabled", "", "NO", " off"} {
+		err := ft.Unmarshal([]byte(`{"bool": "`+s+`"}`), &d, ft.Policy{})
+		is.NoErr(err)
+		is.Equal(false, d.Bool.Bool) // Value must match
+	}
+	err := ft.Unmarshal([]byte(`{"nbool": "maybe"}`), &d, ft.Policy{})
+	is.Equal(`value "maybe" is not a recognised bool`, err.Error())
+
+	// Custom vocabulary
+	p := ft.Policy{
+		TrueStrings:  []string{"ja"},
+		FalseStrings: []string{"nee"},
+	}
+	err = ft.Unmarshal([]byte(`{"bool": "Ja", "nbool": "nee"}`), &d, p)
+	is.NoErr(err)
+	is.Equal(true, d.Bool.Bool)   // Value must match
+	is.Equal(false, d.NBool.Bool) // Value must match
+	err = ft.Unmarshal([]byte(`{"bool": "yes"}`), &d, p)
+	is.Equal(`value "yes" is not a recognised bool`, err.Error())
+
+	// Permissive, only "false", "0" and "" are false
+	p = ft.Policy{BoolStrings: ft.BoolStringsPermissive}
+	err = ft.Unmarshal([]byte(`{"bool": "no", "nbool": "0"}`), &d, p)
+	is.NoErr(err)
+	is.Equal(true, d.Bool.Bool)   // Value must match
+	is.Equal(false, d.NBool.Bool) // Value must match
+
+	// Permissive per field
+	err = ft.Unmarshal([]byte(`{"flag": "abc"}`), &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(true, d.Flag.Bool) // Value must match
+}
+
+func TestDecodeFloatToInt(t *testing.T) {
+	is := is.New(t)
+
+	// Fractions are exact, even where float64 would round them
+	inputs := []string{`2.5`, `-2.5`, `3.5`, `1.9`, `-1.9`, `3.0`, `"2.5"`, `"1e3"`,
+		`1.0000000000000001`, `"3.0000000000000001"`, `2.4999999999999999`, `1e-400`}
+	tests := []struct {
+		mode ft.FloatToInt
+		want []string
+	}{
+		{ft.FloatToIntTruncate, []string{"2", "-2", "3", "1", "-1", "3", "2", "1000",
+			"1", "3", "2", "0"}},
+		{ft.FloatToIntReject, []string{"error", "error", "error", "error",
+			"error", "3", "error", "1000", "error", "error", "error", "error"}},
+		{ft.FloatToIntStrict, []string{"error", "error", "error", "error",
+			"error", "error", "error", "error", "error", "error", "error", "error"}},
+		{ft.FloatToIntFloor, []string{"2", "-3", "3", "1", "-2", "3", "2", "1000",
+			"1", "3", "2", "0"}},
+		{ft.FloatToIntCeil, []string{"3", "-2", "4", "2", "-1", "3", "3", "1000",
+			"2", "4", "3", "1"}},
+		{ft.FloatToIntHalfUp, []string{"3", "-3", "4", "2", "-2", "3", "3", "1000",
+			"1", "3", "2", "0"}},
+		{ft.FloatToIntHalfEven, []string{"2", "-2", "4", "2", "-2", "3", "2", "1000",
+			"1", "3", "2", "0"}},
+	}
+	for _, tt := range tests {
+		p := ft.Policy{FloatToInt: tt.mode}
+		for i, input := range inputs {
+			for _, v := range []interface{}{&ft.Int{}, &ft.NInt{}} {
+				got := "error"
+				if err := ft.Unmarshal([]byte(input), v, p); err == nil {
+					b, err := json.Marshal(v)
+					is.NoErr(err)
+					got = string(b)
+				}
+				is.Equal(tt.want[i], got) // Value must match
+			}
+		}
+	}
+
+	// Integer literals are always accepted
+	d := ft.Int{}
+	p := ft.Policy{FloatToInt: ft.FloatToIntStrict}
+	err := ft.Unmarshal([]byte(`-7`), &d, p)
+	is.NoErr(err)
+	is.Equal(int64(-7), d.Int64) // Value must match
+
+	// Errors are descriptive
+	err = ft.Unmarshal([]byte(`3.0`), &d, p)
+	is.Equal("value 3.0 is not an integer", err.Error())
+	err = ft.Unmarshal([]byte(`"2.5"`), &d, ft.Policy{FloatToInt: ft.FloatToIntReject})
+	is.Equal("value 2.5 has a fractional part", err.Error())
+	err = ft.Unmarshal([]byte(`1.0000000000000001`), &d,
+		ft.Policy{FloatToInt: ft.FloatToIntReject})
+	is.Equal("value 1.0000000000000001 has a fractional part", err.Error())
+
+	// Per field
+	type Data struct {
+		Qty ft.Int `json:"qty" ft:"round=half_even"`
+	}
+	data := Data{}
+	err = ft.Unmarshal([]byte(`{"qty": 2.5}`), &data, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(2), data.Qty.Int64) // Value must match
+}
+
+func TestDecodeOverflow(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		input string
+		v     interface{}
+		err   string
+		cause error
+	}{
+		{`1e20`, &ft.Int{}, "value 1e20 overflows int64", ft.ErrOverflow},
+		{`-1e20`, &ft.NInt{}, "value -1e20 overflows int64", ft.ErrOverflow},
+		{`9223372036854775808`, &ft.Int{},
+			"value 9223372036854775808 overflows int64", ft.ErrOverflow},
+		{`"-9223372036854775809"`, &ft.NInt{},
+			"value -9223372036854775809 overflows int64", ft.ErrOverflow},
+		{`1e400`, &ft.Float{}, "value 1e400 overflows float64", ft.ErrOverflow},
+		{`"-1e400"`, &ft.NFloat{}, "value -1e400 overflows float64", ft.ErrOverflow},
+		{`9007199254740993`, &ft.Float{},
+			"value 9007199254740993 can not be represented exactly as float64",
+			ft.ErrPrecision},
+		{`"9007199254740993"`, &ft.NFloat{},
+			"value 9007199254740993 can not be represented exactly as float64",
+			ft.ErrPrecision},
+		{`9007199254740993.0`, &ft.Float{},
+			"value 9007199254740993.0 can not be represented exactly as float64",
+			ft.ErrPrecision},
+		{`"9007199254740993e0"`, &ft.NFloat{},
+			"value 9007199254740993e0 can not be represented exactly as float64",
+			ft.ErrPrecision},
+		{`1e-400`, &ft.Float{},
+			"value 1e-400 can not be represented exactly as float64",
+			ft.ErrPrecision},
+		{`9223372036854775808.0`, &ft.Int{},
+			"value 9223372036854775808.0 overflows int64", ft.ErrOverflow},
+		{`-9223372036854775809.0`, &ft.Int{},
+			"value -9223372036854775809.0 overflows int64", ft.ErrOverflow},
+	}
+	for _, tt := range tests {
+		err := ft.Unmarshal([]byte(tt.input), tt.v, ft.Policy{})
+		is.Equal(tt.err, err.Error()) // Error must match
+		is.True(errors.Is(err, tt.cause))
+		numErr := &ft.NumberError{}
+		is.True(errors.As(err, &numErr)) // Must be a NumberError
+
+		// UnmarshalJSON uses the default policy
+		err = json.Unmarshal([]byte(tt.input), tt.v)
+		is.True(errors.Is(err, tt.cause))
+	}
+
+	// Representable values are not errors
+	i := ft.Int{}
+	err := ft.Unmarshal([]byte(`9223372036854775807`), &i, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(math.MaxInt64), i.Int64) // Value must match
+	err = ft.Unmarshal([]byte(`1e18`), &i, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(1e18), i.Int64) // Value must match
+	for _, tt := range []struct {
+		input string
+		want  int64
+	}{
+		{`12345678901234567.0`, 12345678901234567},
+		{`9223372036854775807.0`, math.MaxInt64},
+		{`"9.223372036854775807e18"`, math.MaxInt64},
+		{`1234567890123456789.0`, 1234567890123456789},
+	} {
+		err = ft.Unmarshal([]byte(tt.input), &i, ft.Policy{})
+		is.NoErr(err)
+		is.Equal(tt.want, i.Int64) // Value must be exact
+	}
+	f := ft.Float{}
+	err = ft.Unmarshal([]byte(`9007199254740992`), &f, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(float64(1<<53), f.Float64) // Value must match
+	err = ft.Unmarshal([]byte(`0.1`), &f, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(0.1, f.Float64) // Value must match
+	err = ft.Unmarshal([]byte(`0e-400`), &f, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(0.0, f.Float64) // Zero must not underflow
+
+	// Saturate
+	p := ft.Policy{Overflow: ft.OverflowSaturate}
+	err = ft.Unmarshal([]byte(`1e20`), &i, p)
+	is.NoErr(err)
+	is.Equal(int64(math.MaxInt64), i.Int64) // Value must match
+	err = ft.Unmarshal([]byte(`"-99999999999999999999"`), &i, p)
+	is.NoErr(err)
+	is.Equal(int64(math.MinInt64), i.Int64) // Value must match
+	err = ft.Unmarshal([]byte(`-1e400`), &f, p)
+	is.NoErr(err)
+	is.Equal(-math.MaxFloat64, f.Float64) // Value must match
+
+	err = ft.Unmarshal([]byte(`-9223372036854775808.0`), &i, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(math.MinInt64), i.Int64) // Value must match
+
+	// Allow precision loss
+	p = ft.Policy{AllowPrecisionLoss: true}
+	err = ft.Unmarshal([]byte(`9007199254740993`), &f, p)
+	is.NoErr(err)
+	is.Equal(float64(1<<53), f.Float64) // Value must match
+	err = ft.Unmarshal([]byte(`1e-400`), &f, p)
+	is.NoErr(err)
+	is.Equal(0.0, f.Float64) // Value must match
+
+	// Integers are converted with exact arithmetic beyond 2^53
+	bigs := []struct {
+		round ft.FloatToInt
+		input string
+		want  int64
+		err   string
+	}{
+		{ft.FloatToIntTruncate, `9223372036854775807.0`, math.MaxInt64, ""},
+		{ft.FloatToIntTruncate, `12345678901234567.9`, 12345678901234567, ""},
+		{ft.FloatToIntTruncate, `-9223372036854775808.9`, math.MinInt64, ""},
+		{ft.FloatToIntFloor, `9223372036854775807.5`, math.MaxInt64, ""},
+		{ft.FloatToIntFloor, `-9007199254740993.5`, -9007199254740994, ""},
+		{ft.FloatToIntCeil, `9007199254740993.5`, 9007199254740994, ""},
+		{ft.FloatToIntCeil, `9223372036854775807.5`, 0,
+			"value 9223372036854775807.5 overflows int64"},
+		{ft.FloatToIntHalfUp, `-9007199254740992.5`, -9007199254740993, ""},
+		{ft.FloatToIntHalfEven, `9007199254740993.5`, 9007199254740994, ""},
+		{ft.FloatToIntHalfEven, `9007199254740994.5`, 9007199254740994, ""},
+		{ft.FloatToIntReject, `9007199254740993.5`, 0,
+			"value 9007199254740993.5 has a fractional part"},
+		{ft.FloatToIntReject, `9007199254740993.0`, 9007199254740993, ""},
+	}
+	for _, tt := range bigs {
+		i = ft.Int{}
+		err = ft.Unmarshal([]byte(tt.input), &i,
+			ft.Policy{FloatToInt: tt.round})
+		if tt.err != "" {
+			is.Equal(tt.err, err.Error()) // Error must match
+			continue
+		}
+		is.NoErr(err)
+		is.Equal(tt.want, i.Int64) // Value must match
+	}
+
+	// Per field
+	type Data struct {
+		Count ft.Int `json:"count" ft:"overflow=saturate"`
+	}
+	d := Data{}
+	err = ft.Unmarshal([]byte(`{"count": 1e30}`), &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(math.MaxInt64), d.Count.Int64) // Value must match
+}
+
+func TestDecodeNonFinite(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Float  ft.Float  `json:"float"`
+		NFloat ft.NFloat `json:"nfloat"`
+	}
+
+	// Decoded by default, and encoded as strings
+	d := Data{}
+	err := json.Unmarshal([]byte(`{"float": "NaN", "nfloat": "-Inf"}`), &d)
+	is.NoErr(err)
+	is.True(math.IsNaN(d.Float.Float64))      // Must be NaN
+	is.True(math.IsInf(d.NFloat.Float64, -1)) // Must be -Inf
+	b, err := json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"float":"NaN","nfloat":"-Infinity"}`, string(b))
+	is.True(json.Valid(b)) // Must be valid JSON
+
+	// Round trip
+	d = Data{}
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.True(math.IsNaN(d.Float.Float64))      // Must be NaN
+	is.True(math.IsInf(d.NFloat.Float64, -1)) // Must be -Inf
+
+	// Values set in code are also encoded as strings
+	d = Data{
+		Float:  ft.FloatFrom(math.Inf(1)),
+		NFloat: ft.NFloatFrom(math.NaN()),
+	}
+	b, err = json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"float":"Infinity","nfloat":"NaN"}`, string(b))
+	b, err = d.Float.MarshalText()
+	is.NoErr(err)
+	is.Equal("Infinity", string(b))
+
+	// Reject
+	p := ft.Policy{NonFinite: ft.NonFiniteReject}
+	err = ft.Unmarshal([]byte(`{"float": "Infinity"}`), &d, p)
+	is.Equal(`value "Infinity" is not a finite number`, err.Error())
+	err = ft.Unmarshal([]byte(`{"nfloat": "nan"}`), &d, p)
+	is.Equal(`value "nan" is not a finite number`, err.Error())
+	err = ft.Unmarshal([]byte(`{"float": "1.5"}`), &d, p)
+	is.NoErr(err)
+
+	// Null
+	d = Data{}
+	p = ft.Policy{NonFinite: ft.NonFiniteNull}
+	err = ft.Unmarshal([]byte(`{"float": "+Inf", "nfloat": "NaN"}`), &d, p)
+	is.NoErr(err)
+	is.Equal(float64(0), d.Float.Float64) // Value must be zero
+	is.Equal(false, d.NFloat.Valid)       // Must not be valid
+	p.RejectNull = true
+	err = ft.Unmarshal([]byte(`{"nfloat": "NaN"}`), &d, p)
+	is.Equal("value is null", err.Error())
+
+	// Per field
+	type Tagged struct {
+		Float ft.NFloat `json:"float" ft:"nonfinite=reject"`
+	}
+	err = ft.Unmarshal([]byte(`{"float": "NaN"}`), &Tagged{}, ft.Policy{})
+	is.Equal(`value "NaN" is not a finite number`, err.Error())
+}
+
+func TestDecodeRadix(t *testing.T) {
+	is := is.New(t)
+
+	p := ft.Policy{Radix: true}
+	tests := []struct {
+		input string
+		want  int64
+		err   string
+	}{
+		{`"0x1F"`, 31, ""},
+		{`"0XFF_FF"`, 65535, ""},
+		{`"-0x10"`, -16, ""},
+		{`"0b1010"`, 10, ""},
+		{`"0o17"`, 15, ""},
+		{`"017"`, 17, ""},
+		{`"1_000_000"`, 1000000, ""},
+		{`"+42"`, 42, ""},
+		{`"0_17"`, 17, ""},
+		{`"0x7FFFFFFFFFFFFFFF"`, math.MaxInt64, ""},
+		{`"0x8000000000000000"`, 0,
+			"value 0x8000000000000000 overflows int64"},
+		{`"0xZZ"`, 0, `strconv.ParseInt: parsing "0xZZ": invalid syntax`},
+		{`"1__000"`, 0, `strconv.ParseInt: parsing "1__000": invalid syntax`},
+		{`"_1"`, 0, `strconv.ParseInt: parsing "_1": invalid syntax`},
+	}
+	for _, tt := range tests {
+		i := ft.NInt{}
+		err := ft.Unmarshal([]byte(tt.input), &i, p)
+		if tt.err != "" {
+			is.Equal(tt.err, err.Error()) // Error must match
+			continue
+		}
+		is.NoErr(err)
+		is.Equal(tt.want, i.Int64) // Value must match
+	}
+
+	// Saturate
+	i := ft.Int{}
+	p.Overflow = ft.OverflowSaturate
+	err := ft.Unmarshal([]byte(`"-0x8000000000000001"`), &i, p)
+	is.NoErr(err)
+	is.Equal(int64(math.MinInt64), i.Int64) // Value must match
+
+	// Opt-in
+	err = ft.Unmarshal([]byte(`"0x1F"`), &i, ft.Policy{})
+	is.Equal(`strconv.ParseInt: parsing "0x1F": invalid syntax`, err.Error())
+
+	// Per field
+	type Data struct {
+		Reg ft.Int `json:"reg" ft:"radix"`
+	}
+	d := Data{}
+	err = ft.Unmarshal([]byte(`{"reg": "0b1111_0000"}`), &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(240), d.Reg.Int64) // Value must match
+}
+
+func TestDecodeNullStrings(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		String  ft.String  `json:"string"`
+		NString ft.NString `json:"nstring"`
+		NInt    ft.NInt    `json:"nint"`
+		NFloat  ft.NFloat  `json:"nfloat"`
+		NBool   ft.NBool   `json:"nbool"`
+	}
+	b := []byte(`{
+		"string": "None",
+		"nstring": "None",
+		"nint": " n/a ",
+		"nfloat": "NULL",
+		"nbool": "-"
+	}`)
+
+	// Default is empty
+	d := Data{}
+	err := ft.Unmarshal([]byte(`{"nstring": "None"}`), &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(true, d.NString.Valid)    // Must be valid
+	is.Equal("None", d.NString.String) // Value must match
+
+	// Sentinels are null for N-types
+	d = Data{}
+	p := ft.Policy{NullStrings: []string{"None", "null", "N/A", "-"}}
+	err = ft.Unmarshal(b, &d, p)
+	is.NoErr(err)
+	is.Equal("None", d.String.String) // Value must match
+	is.Equal(false, d.NString.Valid)  // Must not be valid
+	is.Equal(false, d.NInt.Valid)     // Must not be valid
+	is.Equal(false, d.NFloat.Valid)   // Must not be valid
+	is.Equal(false, d.NBool.Valid)    // Must not be valid
+
+	// Null may be rejected
+	p.RejectNull = true
+	err = ft.Unmarshal([]byte(`{"nint": "None"}`), &d, p)
+	is.Equal("value is null", err.Error())
+
+	// Per field
+	type Tagged struct {
+		Qty ft.NInt `json:"qty" ft:"nulls=None|undefined"`
+	}
+	tagged := Tagged{Qty: ft.NIntFrom(1)}
+	err = ft.Unmarshal([]byte(`{"qty": "Undefined"}`), &tagged, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(false, tagged.Qty.Valid) // Must not be valid
+}
+
+func TestDecodeEmptyString(t *testing.T) {
+	is := is.New(t)
+
+	types := []func() interface{}{
+		func() interface{} { return &ft.NString{} },
+		func() interface{} { return &ft.NInt{} },
+		func() interface{} { return &ft.NFloat{} },
+		func() interface{} { return &ft.NBool{} },
+		func() interface{} { return &ft.String{} },
+		func() interface{} { return &ft.Int{} },
+		func() interface{} { return &ft.Float{} },
+		func() interface{} { return &ft.Bool{} },
+	}
+	tests := []struct {
+		mode ft.EmptyString
+		want []string
+	}{
+		{ft.EmptyStringKeep, []string{
+			`""`, "null", "null", "false", `""`, "error", "error", "false"}},
+		{ft.EmptyStringNull, []string{
+			"null", "null", "null", "null", `""`, "0", "0", "false"}},
+		{ft.EmptyStringZero, []string{
+			`""`, "0", "0", "false", `""`, "0", "0", "false"}},
+		{ft.EmptyStringError, []string{
+			"error", "error", "error", "error",
+			"error", "error", "error", "error"}},
+	}
+	for _, tt := range tests {
+		p := ft.Policy{EmptyString: tt.mode}
+		for _, input := range []string{`""`, `" \t"`} {
+			for i, newType := range types {
+				want := tt.want[i]
+				if tt.mode == ft.EmptyStringKeep && want == `""` {
+					// Strings are kept as is
+					want = input
+				}
+				got := "error"
+				v := newType()
+				if err := ft.Unmarshal([]byte(input), v, p); err == nil {
+					b, err := json.Marshal(v)
+					is.NoErr(err)
+					got = string(b)
+				}
+				is.Equal(want, got) // Value must match
+			}
+		}
+	}
+
+	// Error message
+	err := ft.Unmarshal([]byte(`""`), &ft.NString{},
+		ft.Policy{EmptyString: ft.EmptyStringError})
+	is.Equal("value is an empty string", err.Error())
+
+	// Null may be rejected
+	err = ft.Unmarshal([]byte(`""`), &ft.NInt{},
+		ft.Policy{EmptyString: ft.EmptyStringNull, RejectNull: true})
+	is.Equal("value is null", err.Error())
+
+	// Per field
+	type Data struct {
+		Name ft.NString `json:"name" ft:"empty=null"`
+	}
+	d := Data{}
+	err = ft.Unmarshal([]byte(`{"name": "  "}`), &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(false, d.Name.Valid) // Must not be valid
+}
+
+func TestDecodeStringComposite(t *testing.T) {
+	is := is.New(t)
+
+	// Numbers are verbatim
+	s := ft.String{}
+	err := json.Unmarshal([]byte(`1e400`), &s)
+	is.NoErr(err)
+	is.Equal("1e400", s.String) // Value must match
+	err = json.Unmarshal([]byte(`12345678901234567890.10`), &s)
+	is.NoErr(err)
+	is.Equal("12345678901234567890.10", s.String) // Value must match
+
+	// Objects and arrays are an error by default
+	ns := ft.NString{}
+	err = json.Unmarshal([]byte(`{"a": 1}`), &ns)
+	is.Equal("cannot convert object to string", err.Error())
+	err = json.Unmarshal([]byte(`[1, 2]`), &ns)
+	is.Equal("cannot convert array to string", err.Error())
+
+	// Raw
+	p := ft.Policy{StringComposite: ft.StringCompositeRaw}
+	err = ft.Unmarshal([]byte(`{ "a": [1, 2], "b": null }`), &ns, p)
+	is.NoErr(err)
+	is.Equal(`{"a":[1,2],"b":null}`, ns.String) // Value must match
+	err = ft.Unmarshal([]byte(`[ "x" ]`), &ns, p)
+	is.NoErr(err)
+	is.Equal(`["x"]`, ns.String) // Value must match
+
+	// Join
+	p = ft.Policy{StringComposite: ft.StringCompositeJoin}
+	err = ft.Unmarshal([]byte(`["a", 1, true, null, 2.50]`), &s, p)
+	is.NoErr(err)
+	is.Equal("a,1,true,,2.50", s.String) // Value must match
+	p.JoinSeparator = ", "
+	err = ft.Unmarshal([]byte(`["a", ["b", "c"]]`), &s, p)
+	is.NoErr(err)
+	is.Equal("a, b, c", s.String) // Value must match
+	err = ft.Unmarshal([]byte(`[]`), &s, p)
+	is.NoErr(err)
+	is.Equal("", s.String) // Value must match
+	err = ft.Unmarshal([]byte(`{"a": 1}`), &s, p)
+	is.Equal("cannot convert object to string", err.Error())
+	err = ft.Unmarshal([]byte(`["a", {}]`), &s, p)
+	is.Equal("cannot convert object to string", err.Error())
+
+	// Kinds take precedence
+	p.StringKinds = ft.KindString
+	err = ft.Unmarshal([]byte(`["a"]`), &s, p)
+	is.Equal("cannot convert array to string", err.Error())
+
+	// Per field
+	type Data struct {
+		Tags ft.String `json:"tags" ft:"composite=join"`
+		Meta ft.String `json:"meta" ft:"composite=raw"`
+	}
+	d := Data{}
+	err = ft.Unmarshal([]byte(`{"tags": ["a", "b"], "meta": {"k": "v"}}`), &d,
+		ft.Policy{})
+	is.NoErr(err)
+	is.Equal("a,b", d.Tags.String)       // Value must match
+	is.Equal(`{"k":"v"}`, d.Meta.String) // Value must match
+}
+
+func TestDecodeUnwrapArrays(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		String ft.String `json:"string"`
+		Int    ft.Int    `json:"int"`
+		Float  ft.Float  `json:"float"`
+		Bool   ft.Bool   `json:"bool"`
+		NInt   ft.NInt   `json:"nint"`
+		NBool  ft.NBool  `json:"nbool"`
+	}
+	b := []byte(`{
+		"string": ["abc"],
+		"int": ["3"],
+		"float": [1.5],
+		"bool": [true],
+		"nint": [null],
+		"nbool": ["yes"]
+	}`)
+
+	// Opt-in
+	d := Data{}
+	err := ft.Unmarshal(b, &d, ft.Policy{})
+	is.Equal("cannot convert array to string", err.Error())
+
+	p := ft.Policy{UnwrapArrays: true}
+	err = ft.Unmarshal(b, &d, p)
+	is.NoErr(err)
+	is.Equal("abc", d.String.String) // Value must match
+	is.Equal(int64(3), d.Int.Int64)  // Value must match
+	is.Equal(1.5, d.Float.Float64)   // Value must match
+	is.Equal(true, d.Bool.Bool)      // Value must match
+	is.Equal(false, d.NInt.Valid)    // Must not be valid
+	is.Equal(true, d.NBool.Bool)     // Value must match
+	is.Equal(true, d.NBool.Valid)    // Must be valid
+
+	// Other arrays will error
+	err = ft.Unmarshal([]byte(`{"int": ["1", "2"]}`), &d, p)
+	is.Equal("array must have 1 element, found 2", err.Error())
+	err = ft.Unmarshal([]byte(`{"bool": []}`), &d, p)
+	is.Equal("array must have 1 element, found 0", err.Error())
+	err = ft.Unmarshal([]byte(`{"float": [[1]]}`), &d, p)
+	is.Equal("value is an array", err.Error())
+
+	// Per field
+	type Tagged struct {
+		Qty ft.NInt `json:"qty" ft:"unwrap"`
+	}
+	tagged := Tagged{}
+	err = ft.Unmarshal([]byte(`{"qty": ["3"]}`), &tagged, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(3), tagged.Qty.Int64) // Value must match
+
+	// StringComposite takes precedence for strings
+	tests := []struct {
+		composite ft.StringComposite
+		input     string
+		want      string
+	}{
+		{ft.StringCompositeJoin, `{"string": ["a", "b"]}`, "a,b"},
+		{ft.StringCompositeJoin, `{"string": ["a"]}`, "a"},
+		{ft.StringCompositeRaw, `{"string": ["a"]}`, `["a"]`},
+		{ft.StringCompositeRaw, `{"string": []}`, `[]`},
+	}
+	for _, tt := range tests {
+		d := Data{}
+		err := ft.Unmarshal([]byte(tt.input), &d,
+			ft.Policy{UnwrapArrays: true, StringComposite: tt.composite})
+		is.NoErr(err)
+		is.Equal(tt.want, d.String.String) // Value must match
+	}
+	d = Data{}
+	err = ft.Unmarshal([]byte(`{"int": ["3"]}`), &d, ft.Policy{
+		UnwrapArrays: true, StringComposite: ft.StringCompositeRaw})
+	is.NoErr(err)
+	is.Equal(int64(3), d.Int.Int64) // Other types must be unwrapped
+}
+
+func TestDecodeKeyMatch(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		SKU   ft.String `json:"sku"`
+		SKUID ft.Int    `json:"skuId" ft:"alias=sku_code|code"`
+	}
+
+	tests := []struct {
+		match ft.KeyMatch
+		input string
+		sku   string
+		id    int64
+	}{
+		{ft.KeyMatchFold, `{"SKU": "a", "SKUID": 1}`, "a", 1},
+		{ft.KeyMatchFold, `{"Sku": "a", "sku_id": 1}`, "a", 0},
+		{ft.KeyMatchFold, `{"CODE": 2}`, "", 2},
+		{ft.KeyMatchExact, `{"SKU": "a", "skuId": 1}`, "", 1},
+		{ft.KeyMatchExact, `{"sku_code": 3}`, "", 3},
+		{ft.KeyMatchStyle, `{"SKU": "a", "sku_id": 1}`, "a", 1},
+		{ft.KeyMatchStyle, `{"Sku": "a", "sku-id": 1}`, "a", 1},
+		{ft.KeyMatchStyle, `{"sku.id": 1}`, "", 1},
+		{ft.KeyMatchStyle, `{"SKU_CODE": 4}`, "", 4},
+	}
+	for _, tt := range tests {
+		d := Data{}
+		err := ft.Unmarshal([]byte(tt.input), &d, ft.Policy{KeyMatch: tt.match})
+		is.NoErr(err)
+		is.Equal(tt.sku, d.SKU.String) // Value must match
+		is.Equal(tt.id, d.SKUID.Int64) // Value must match
+	}
+
+	// Keys that match more than one field
+	type Ambiguous struct {
+		SkuID  ft.Int `json:"sku_id"`
+		SkuId2 ft.Int `json:"skuId"`
+	}
+	a := Ambiguous{}
+	p := ft.Policy{KeyMatch: ft.KeyMatchStyle}
+	err := ft.Unmarshal([]byte(`{"SKU-ID": 1}`), &a, p)
+	is.Equal(`key "SKU-ID" matches fields "sku_id" and "skuId"`, err.Error())
+	err = ft.Unmarshal([]byte(`{"skuId": 1}`), &a, p)
+	is.NoErr(err)                      // Exact match is preferred
+	is.Equal(int64(1), a.SkuId2.Int64) // Value must match
+
+	// Aliases must not be the name of another field
+	type Conflict struct {
+		A ft.Int `json:"a" ft:"alias=b"`
+		B ft.Int `json:"b"`
+	}
+	err = ft.Unmarshal([]byte(`{}`), &Conflict{}, ft.Policy{})
+	is.Equal(`ft: alias "b" of field "a" in ft_test.Conflict is the name of field "b"`,
+		err.Error())
+
+	// Per field
+	type Webhook struct {
+		Item Data `json:"item" ft:"keys=style"`
+	}
+	w := Webhook{}
+	err = ft.Unmarshal([]byte(`{"item": {"sku-id": 5}}`), &w, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(5), w.Item.SKUID.Int64) // Value must match
+}
+
+// BenchmarkUnmarshalDepth decodes nested documents, the time per op
+// must grow linearly with the depth, like encoding/json
+func BenchmarkUnmarshalDepth(b *testing.B) {
+	type Node struct {
+		A []Node `json:"a"`
+	}
+	for _, depth := range []int{10, 100, 1000, 4000} {
+		data := []byte(strings.Repeat(`{"a":[`, depth) +
+			strings.Repeat(`]}`, depth))
+		b.Run(fmt.Sprintf("ft/%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := ft.Unmarshal(data, &Node{}, ft.Policy{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("json/%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := json.Unmarshal(data, &Node{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		arrays := []byte(strings.Repeat(`[`, depth) + strings.Repeat(`]`, depth))
+		b.Run(fmt.Sprintf("duplicates/%d", depth), func(b *testing.B) {
+			p := ft.Policy{DuplicateKeys: ft.DuplicateKeysError}
+			for i := 0; i < b.N; i++ {
+				v := []interface{}{}
+				if err := ft.Unmarshal(arrays, &v, p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkUnmarshalSize decodes wide documents
+func BenchmarkUnmarshalSize(b *testing.B) {
+	type Item struct {
+		Price ft.Int    `json:"price"`
+		Name  ft.String `json:"name"`
+	}
+	for _, n := range []int{10, 1000, 10000} {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = fmt.Sprintf(`{"price": "%d", "name": "item %d"}`, i, i)
+		}
+		data := []byte("[" + strings.Join(items, ",") + "]")
+		b.Run(fmt.Sprintf("ft/%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				v := []Item{}
+				if err := ft.Unmarshal(data, &v, ft.Policy{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package ft
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DuplicateKeys controls how objects with the same key more than once,
+// e.g. {"price": 1, "price": "2"}, are decoded by Unmarshal and Decoder.
+// The policy applies to the whole document at any depth, before values
+// are decoded, struct tags can not override it. Keys that differ but match
+// the same struct field, e.g. "price" and "PRICE", or a name and an alias,
+// are also duplicates, see KeyMatch
+type DuplicateKeys uint8
+
+const (
+	// DuplicateKeysAllow decodes the members in order like encoding/json,
+	// this is the default. Scalars are overwritten so the last value
+	// wins, but objects decoded into the same struct are merged
+	DuplicateKeysAllow DuplicateKeys = iota
+	// DuplicateKeysError returns an error with the JSON path of the
+	// duplicate key, e.g. $.items[0].price
+	DuplicateKeysError
+	// DuplicateKeysFirst decodes the first value and ignores the others
+	DuplicateKeysFirst
+	// DuplicateKeysLast decodes the last value and ignores the others
+	DuplicateKeysLast
+)
+
+// duplicates applies the DuplicateKeys policy to the document bArr,
+// that is indexed by ds. For the first and last policy the members that
+// are ignored are removed, and the document is indexed again
+func (ds *decodeState) duplicates(bArr []byte, p *Policy) ([]byte, error) {
+	x, i, err := ds.locate(bArr)
+	if err != nil {
+		return bArr, err
+	}
+	w := &duplicateWalker{x: x, p: p}
+	if err = w.value(i); err != nil || len(w.drops) == 0 {
+		return bArr, err
+	}
+
+	// Ranges inside a removed member are skipped
+	sort.Slice(w.drops, func(i, j int) bool {
+		return w.drops[i][0] < w.drops[j][0]
+	})
+	out := make([]byte, 0, len(x.data))
+	cursor := 0
+	for _, r := range w.drops {
+		if r[0] < cursor {
+			continue
+		}
+		out = append(out, x.data[cursor:r[0]]...)
+		cursor = r[1]
+	}
+	out = append(out, x.data[cursor:]...)
+	ds.index = newJSONIndex(out)
+	return out, nil
+}
+
+// duplicateWalker finds duplicate keys in an indexed document
+type duplicateWalker struct {
+	x *jsonIndex
+	p *Policy
+	// path segments of the current value, e.g. ".items" and "[0]"
+	path []string
+	// drops are the ranges of members that are removed
+	drops [][2]int
+}
+
+// pathTo returns the JSON path of the key in the current object
+func (w *duplicateWalker) pathTo(key string) string {
+	return "$" + strings.Join(w.path, "") + memberSegment(key)
+}
+
+// value walks the value at offset i
+func (w *duplicateWalker) value(i int) error {
+	switch w.x.data[i] {
+	case '{':
+		return w.object(i)
+	case '[':
+		return w.x.elements(i, func(n int, val []byte) error {
+			w.path = append(w.path, "["+strconv.Itoa(n)+"]")
+			err := w.value(w.valueOffset(val))
+			w.path = w.path[:len(w.path)-1]
+			return err
+		})
+	}
+	return nil
+}
+
+// valueOffset returns the offset of val, that is a sub-slice of the index
+func (w *duplicateWalker) valueOffset(val []byte) int {
+	return cap(w.x.data) - cap(val)
+}
+
+// object walks the members of the object at offset i
+func (w *duplicateWalker) object(i int) error {
+	type member struct {
+		start, end int
+		drop       bool
+	}
+	members := []member{}
+	index := map[string]int{}
+	err := w.x.members(i, func(start, end int, key string, val []byte) error {
+		m := member{start: start, end: end}
+		if first, ok := index[key]; ok {
+			switch w.p.DuplicateKeys {
+			case DuplicateKeysError:
+				return errors.Errorf("duplicate key at %s", w.pathTo(key))
+			case DuplicateKeysFirst:
+				m.drop = true
+			case DuplicateKeysLast:
+				members[first].drop = true
+			}
+		}
+		index[key] = len(members)
+		members = append(members, m)
+
+		w.path = append(w.path, memberSegment(key))
+		err := w.value(w.valueOffset(val))
+		w.path = w.path[:len(w.path)-1]
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Remove runs of dropped members with the comma before them,
+	// or after them if the run is at the start of the object
+	for j := 0; j < len(members); j++ {
+		if !members[j].drop {
+			continue
+		}
+		k := j
+		for k+1 < len(members) && members[k+1].drop {
+			k++
+		}
+		if j == 0 {
+			w.drops = append(w.drops, [2]int{members[0].start, members[k+1].start})
+		} else {
+			w.drops = append(w.drops, [2]int{members[j-1].end, members[k].end})
+		}
+		j = k
+	}
+	return nil
+}
+
+// memberSegment returns the JSON path segment for the key,
+// e.g. .name or ["first name"]
+func memberSegment(key string) string {
+	if isIdentifier(key) {
+		return "." + key
+	}
+	return "[" + string(QuoteJSON(key, 0)) + "]"
+}
@@ -0,0 +1,127 @@
+package ft_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestDuplicateKeys(t *testing.T) {
+	is := is.New(t)
+
+	type Item struct {
+		Price ft.Int    `json:"price"`
+		Name  ft.String `json:"name"`
+	}
+	type Data struct {
+		Items []Item `json:"items"`
+		Meta  map[string]interface{}
+	}
+
+	tests := []struct {
+		dup   ft.DuplicateKeys
+		input string
+		want  int64
+		err   string
+	}{
+		{ft.DuplicateKeysAllow, `{"items": [{"price": 1, "price": "2"}]}`, 2, ""},
+		{ft.DuplicateKeysFirst, `{"items": [{"price": 1, "price": "2"}]}`, 1, ""},
+		{ft.DuplicateKeysLast, `{"items": [{"price": 1, "price": "2"}]}`, 2, ""},
+		{ft.DuplicateKeysError, `{"items": [{"price": 1, "price": "2"}]}`, 0,
+			"duplicate key at $.items[0].price"},
+		{ft.DuplicateKeysError, `{"items": [], "items": []}`, 0,
+			"duplicate key at $.items"},
+		{ft.DuplicateKeysError, `{"Meta": {"a b": {"x": 1, "x": 2}}}`, 0,
+			`duplicate key at $.Meta["a b"].x`},
+		{ft.DuplicateKeysError, `{"Other": {"x": 1, "x": 2}}`, 0,
+			"duplicate key at $.Other.x"},
+		{ft.DuplicateKeysError, `{"items": [{"price": 1}, {"price": 2}]}`, 1, ""},
+	}
+	for _, tt := range tests {
+		d := Data{}
+		err := ft.Unmarshal([]byte(tt.input), &d, ft.Policy{DuplicateKeys: tt.dup})
+		if tt.err != "" {
+			is.Equal(tt.err, err.Error()) // Error must match
+			continue
+		}
+		is.NoErr(err)
+		is.Equal(tt.want, d.Items[0].Price.Int64) // Value must match
+	}
+
+	// Objects are not merged
+	input := `{"items": [{"name": "a"}], "items": [{"price": 3}]}`
+	d := Data{}
+	err := ft.Unmarshal([]byte(input), &d,
+		ft.Policy{DuplicateKeys: ft.DuplicateKeysLast})
+	is.NoErr(err)
+	is.Equal([]Item{{Price: ft.IntFrom(3)}}, d.Items) // Value must match
+	d = Data{}
+	err = ft.Unmarshal([]byte(input), &d,
+		ft.Policy{DuplicateKeys: ft.DuplicateKeysFirst})
+	is.NoErr(err)
+	is.Equal([]Item{{Name: ft.StringFrom("a")}}, d.Items) // Value must match
+
+	// Values of other types
+	m := map[string]interface{}{}
+	err = ft.Unmarshal([]byte(`{"a": {"b": 1, "b": 2}}`), &m,
+		ft.Policy{DuplicateKeys: ft.DuplicateKeysFirst})
+	is.NoErr(err)
+	is.Equal(map[string]interface{}{"b": 1.0}, m["a"]) // Value must match
+
+	// Keys that match the same field
+	type Sku struct {
+		SkuID ft.String `json:"sku_id" ft:"alias=code"`
+	}
+	variants := []struct {
+		match ft.KeyMatch
+		input string
+		path  string
+	}{
+		{ft.KeyMatchFold, `{"sku_id": "1", "SKU_ID": "2"}`, "$.SKU_ID"},
+		{ft.KeyMatchStyle, `{"sku_id": "1", "skuId": "2"}`, "$.skuId"},
+		{ft.KeyMatchExact, `{"sku_id": "1", "code": "2"}`, "$.code"},
+		{ft.KeyMatchFold, `{"CODE": "1", "sku_id": "2"}`, "$.sku_id"},
+	}
+	for _, tt := range variants {
+		for _, dup := range []ft.DuplicateKeys{
+			ft.DuplicateKeysAllow, ft.DuplicateKeysFirst,
+			ft.DuplicateKeysLast, ft.DuplicateKeysError,
+		} {
+			sku := Sku{}
+			err := ft.Unmarshal([]byte(tt.input), &sku,
+				ft.Policy{KeyMatch: tt.match, DuplicateKeys: dup})
+			switch dup {
+			case ft.DuplicateKeysError:
+				is.Equal("duplicate key at "+tt.path, err.Error()) // Error must match
+			case ft.DuplicateKeysFirst:
+				is.NoErr(err)
+				is.Equal("1", sku.SkuID.String) // First value must win
+			default:
+				is.NoErr(err)
+				is.Equal("2", sku.SkuID.String) // Last value must win
+			}
+		}
+	}
+	items := []Item{}
+	err = ft.Unmarshal([]byte(`[{}, {"price": 1, "PRICE": 2}]`), &items,
+		ft.Policy{DuplicateKeys: ft.DuplicateKeysError})
+	is.Equal("duplicate key at $[1].PRICE", err.Error())
+
+	// Objects matched by variants are not merged
+	type Wrapper struct {
+		Item Item `json:"item"`
+	}
+	w := Wrapper{}
+	err = ft.Unmarshal([]byte(`{"item": {"name": "a"}, "ITEM": {"price": 3}}`),
+		&w, ft.Policy{DuplicateKeys: ft.DuplicateKeysLast})
+	is.NoErr(err)
+	is.Equal(Item{Price: ft.IntFrom(3)}, w.Item) // Value must match
+
+	// Decoder
+	dec := ft.NewDecoder(strings.NewReader(`{"price": 1, "price": 2}`))
+	dec.SetPolicy(ft.Policy{DuplicateKeys: ft.DuplicateKeysError})
+	err = dec.Decode(&Item{})
+	is.Equal("duplicate key at $.price", err.Error())
+}
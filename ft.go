@@ -1,15 +1,13 @@
 package ft
 
 import (
-	"encoding/json"
-	"fmt"
+	"math"
 	"strconv"
-	"strings"
-
-	"github.com/pkg/errors"
 )
 
-// String can be used to decode any JSON value to string
+// String can be used to decode any JSON value to string.
+// Numbers are decoded verbatim, objects and arrays
+// will error, see Policy.StringComposite
 type String struct {
 	String string
 }
@@ -22,48 +20,20 @@ func StringFrom(fs string) String {
 // Method must not have a pointer receiver!
 // See https://stackoverflow.com/a/21394657/639133
 func (fs String) MarshalJSON() ([]byte, error) {
-	// Control characters like "Start of Text" \u0002 breaks MarshalJSON
-	// after calling Quote and wrapping the string with RawMessage.
-	// First call Clean to remove non-graphic characters (except newline)
-	return json.RawMessage(strconv.Quote(Clean(fs.String))), nil
+	return QuoteJSON(fs.String, 0), nil
 }
 
 // UnmarshalJSON for String
 func (fs *String) UnmarshalJSON(bArr []byte) (err error) {
-	s, i, f, b :=
-		"", uint64(0), float64(0), false
-
-	// Value is null
-	if string(bArr) == "null" {
-		*fs = StringFrom("")
-		return
-	}
-
-	// Value is a...
-	// string
-	if err = json.Unmarshal(bArr, &s); err == nil {
-		*fs = StringFrom(s)
-		return
-	}
-
-	// int
-	if err = json.Unmarshal(bArr, &i); err == nil {
-		*fs = StringFrom(string(bArr[:]))
-		return
-	}
-
-	// float
-	if err = json.Unmarshal(bArr, &f); err == nil {
-		*fs = StringFrom(string(bArr[:]))
-		return
-	}
+	return fs.unmarshalJSON(bArr, &defaultPolicy)
+}
 
-	// bool
-	if err = json.Unmarshal(bArr, &b); err == nil {
-		*fs = StringFrom(string(bArr[:]))
-		return
+func (fs *String) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	s, _, err := coerceString(bArr, p, false)
+	if err != nil {
+		return err
 	}
-
+	*fs = StringFrom(s)
 	return
 }
 
@@ -76,11 +46,17 @@ func (fs String) MarshalText() (text []byte, err error) {
 }
 
 func (fs *String) UnmarshalText(text []byte) error {
-	return fs.UnmarshalJSON(text)
+	return fs.unmarshalText(text, &defaultPolicy)
+}
+
+func (fs *String) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, false)
+	return fs.unmarshalJSON(bArr, tp)
 }
 
 // Int can be used to decode any JSON value to int64.
 // Strings that are not valid representation of a number will error.
+// The fractional part of numbers is truncated, see Policy.FloatToInt.
 // Boolean values will error
 type Int struct {
 	Int64 int64
@@ -97,46 +73,15 @@ func (fi Int) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON method for Int
 func (fi *Int) UnmarshalJSON(bArr []byte) (err error) {
-	s, i, f, b :=
-		"", int64(0), float64(0), false
-
-	// Value is null
-	if string(bArr) == "null" {
-		*fi = IntFrom(0)
-		return
-	}
-
-	// Value is a...
-	// string
-	if err = json.Unmarshal(bArr, &s); err == nil {
-		i, err2 := strconv.ParseInt(s, 10, 64)
-		if err2 != nil {
-			// Value is null if int could not be parsed from the string
-			//*fi = Int(0) // This is not a good idea...
-			return err2
-		}
-		*fi = IntFrom(i)
-		return
-	}
-
-	// int
-	if err = json.Unmarshal(bArr, &i); err == nil {
-		*fi = IntFrom(i)
-		return
-	}
-
-	// float
-	if err = json.Unmarshal(bArr, &f); err == nil {
-		*fi = IntFrom(int64(f))
-		return
-	}
+	return fi.unmarshalJSON(bArr, &defaultPolicy)
+}
 
-	// bool
-	if err = json.Unmarshal(bArr, &b); err == nil {
-		//*fi = Int(0) // This is not a good idea...
-		return errors.WithStack(fmt.Errorf("value is a bool"))
+func (fi *Int) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	i, _, err := coerceInt(bArr, p, false)
+	if err != nil {
+		return err
 	}
-
+	*fi = IntFrom(i)
 	return
 }
 
@@ -145,12 +90,19 @@ func (fi Int) MarshalText() (text []byte, err error) {
 }
 
 func (fi *Int) UnmarshalText(text []byte) error {
-	return fi.UnmarshalJSON(text)
+	return fi.unmarshalText(text, &defaultPolicy)
+}
+
+func (fi *Int) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, false)
+	return fi.unmarshalJSON(bArr, tp)
 }
 
 // Float can be used to decode any JSON value to int64.
 // Strings that are not valid representation of a number will error.
-// Boolean values will error
+// Boolean values will error.
+// Strings like "NaN" and "-Infinity" are decoded as per Policy.NonFinite,
+// non-finite values are encoded as JSON strings
 type Float struct {
 	Float64 float64
 }
@@ -161,65 +113,62 @@ func FloatFrom(ff float64) Float {
 
 // MarshalJSON method for Float
 func (ff Float) MarshalJSON() ([]byte, error) {
-	return []byte(strconv.FormatFloat(ff.Float64, 'f', -1, 64)), nil
+	return marshalFloat(ff.Float64), nil
 }
 
 // UnmarshalJSON method for Float
 func (ff *Float) UnmarshalJSON(bArr []byte) (err error) {
-	s, i, f, b :=
-		"", int64(0), float64(0), false
-
-	// Value is null
-	if string(bArr) == "null" {
-		*ff = FloatFrom(0)
-		return
-	}
-
-	// Value is a...
-	// string
-	if err = json.Unmarshal(bArr, &s); err == nil {
-		i, err2 := strconv.ParseFloat(s, 64)
-		if err2 != nil {
-			// Value is null if int could not be parsed from the string
-			//*fi = Float(0) // This is not a good idea...
-			return err2
-		}
-		*ff = FloatFrom(i)
-		return
-	}
-
-	// int
-	if err = json.Unmarshal(bArr, &i); err == nil {
-		*ff = FloatFrom(float64(i))
-		return
-	}
-
-	// float
-	if err = json.Unmarshal(bArr, &f); err == nil {
-		*ff = FloatFrom(f)
-		return
-	}
+	return ff.unmarshalJSON(bArr, &defaultPolicy)
+}
 
-	// bool
-	if err = json.Unmarshal(bArr, &b); err == nil {
-		//*ff = Float(0) // This is not a good idea...
-		return errors.WithStack(fmt.Errorf("value is a bool"))
+func (ff *Float) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	f, _, err := coerceFloat(bArr, p, false)
+	if err != nil {
+		return err
 	}
-
+	*ff = FloatFrom(f)
 	return
 }
 
 func (ff Float) MarshalText() (text []byte, err error) {
-	return []byte(strconv.FormatFloat(ff.Float64, 'f', -1, 64)), nil
+	return []byte(formatFloat(ff.Float64)), nil
 }
 
 func (ff *Float) UnmarshalText(text []byte) error {
-	return ff.UnmarshalJSON(text)
+	return ff.unmarshalText(text, &defaultPolicy)
+}
+
+func (ff *Float) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, false)
+	return ff.unmarshalJSON(bArr, tp)
+}
+
+// formatFloat formats f without an exponent.
+// Non-finite values are formatted as NaN, Infinity and -Infinity
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// marshalFloat returns f as JSON, non-finite values are JSON strings
+func marshalFloat(f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return []byte(strconv.Quote(formatFloat(f)))
+	}
+	return []byte(formatFloat(f))
 }
 
 // Bool can be used to decode any JSON value to bool.
-// Empty strings as well as "false" and "0" evaluate to false,
-// all other strings are true.
+// Strings like "true", "yes" and "on" evaluate to true,
+// and "false", "no", "off" and "" evaluate to false.
+// Other strings will error, see Policy.BoolStrings.
 // Numbers equal to 0 will evaluate to false,
 // all other numbers are true.
 type Bool struct {
@@ -237,53 +186,15 @@ func (fb Bool) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON method for Bool
 func (fb *Bool) UnmarshalJSON(bArr []byte) (err error) {
-	s, i, f, b :=
-		"", int64(0), float64(0), false
-
-	// Value is null
-	if string(bArr) == "null" {
-		*fb = BoolFrom(false)
-		return
-	}
-
-	// Value is a...
-	// string
-	if err = json.Unmarshal(bArr, &s); err == nil {
-		compare := strings.ToLower(strings.TrimSpace(s))
-		if compare == "false" || compare == "0" || compare == "" {
-			*fb = BoolFrom(false)
-			return
-		}
-		*fb = BoolFrom(true)
-		return
-	}
-
-	// int
-	if err = json.Unmarshal(bArr, &i); err == nil {
-		if i == 0 {
-			*fb = BoolFrom(false)
-			return
-		}
-		*fb = BoolFrom(true)
-		return
-	}
-
-	// float
-	if err = json.Unmarshal(bArr, &f); err == nil {
-		if f == 0 {
-			*fb = BoolFrom(false)
-			return
-		}
-		*fb = BoolFrom(true)
-		return
-	}
+	return fb.unmarshalJSON(bArr, &defaultPolicy)
+}
 
-	// bool
-	if err = json.Unmarshal(bArr, &b); err == nil {
-		*fb = BoolFrom(b)
-		return
+func (fb *Bool) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	b, _, err := coerceBool(bArr, p, false)
+	if err != nil {
+		return err
 	}
-
+	*fb = BoolFrom(b)
 	return
 }
 
@@ -292,5 +203,10 @@ func (fb Bool) MarshalText() (text []byte, err error) {
 }
 
 func (fb *Bool) UnmarshalText(text []byte) error {
-	return fb.UnmarshalJSON(text)
+	return fb.unmarshalText(text, &defaultPolicy)
+}
+
+func (fb *Bool) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, false)
+	return fb.unmarshalJSON(bArr, tp)
 }
@@ -223,11 +223,20 @@ func TestUnmarshalBool(t *testing.T) {
 	is.NoErr(err)
 	is.Equal(true, d.Bool.Bool) // Value must match
 
-	b = []byte(`{"bool": "abc"}`)
+	b = []byte(`{"bool": "yes"}`)
 	err = json.Unmarshal(b, &d)
 	is.NoErr(err)
 	is.Equal(true, d.Bool.Bool) // Value must match
 
+	b = []byte(`{"bool": " Off "}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(false, d.Bool.Bool) // Value must match
+
+	b = []byte(`{"bool": "abc"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal(`value "abc" is not a recognised bool`, err.Error())
+
 	// int
 	b = []byte(`{"bool": 0}`)
 	err = json.Unmarshal(b, &d)
@@ -1,16 +1,16 @@
 package ft
 
 import (
-	"encoding/json"
 	"strconv"
 	"strings"
 	"unicode"
 
 	"github.com/guregu/null"
-	"github.com/pkg/errors"
 )
 
-// NString can be used to decode any JSON value to string
+// NString can be used to decode any JSON value to string.
+// Numbers are decoded verbatim, objects and arrays
+// will error, see Policy.StringComposite
 type NString null.String
 
 func NStringFrom(fs string) NString {
@@ -39,48 +39,24 @@ func (fs NString) MarshalJSON() ([]byte, error) {
 	if !fs.Valid {
 		return []byte(`null`), nil
 	}
-	// Control characters like "Start of Text" \u0002 breaks MarshalJSON
-	// after calling Quote and wrapping the string with RawMessage.
-	// First call Clean to remove non-graphic characters (except newline)
-	return json.RawMessage(strconv.Quote(Clean(fs.String))), nil
+	return QuoteJSON(fs.String, 0), nil
 }
 
 // UnmarshalJSON for String
 func (fs *NString) UnmarshalJSON(bArr []byte) (err error) {
-	s, i, f, b :=
-		string(""), uint64(0), float64(0), false
-
-	// Value is null
-	if string(bArr) == "null" {
-		*fs = NString(null.String{})
-		return
-	}
-
-	// Value is a...
-	// string
-	if err = json.Unmarshal(bArr, &s); err == nil {
-		*fs = NString(null.StringFrom(s))
-		return
-	}
-
-	// int
-	if err = json.Unmarshal(bArr, &i); err == nil {
-		*fs = NString(null.StringFrom(string(bArr[:])))
-		return
-	}
+	return fs.unmarshalJSON(bArr, &defaultPolicy)
+}
 
-	// float
-	if err = json.Unmarshal(bArr, &f); err == nil {
-		*fs = NString(null.StringFrom(string(bArr[:])))
-		return
+func (fs *NString) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	s, valid, err := coerceString(bArr, p, true)
+	if err != nil {
+		return err
 	}
-
-	// bool
-	if err = json.Unmarshal(bArr, &b); err == nil {
-		*fs = NString(null.StringFrom(string(bArr[:])))
+	if !valid {
+		*fs = NString(null.String{})
 		return
 	}
-
+	*fs = NString(null.StringFrom(s))
 	return
 }
 
@@ -90,18 +66,23 @@ func (fs *NString) UnmarshalJSON(bArr []byte) (err error) {
 
 func (fs NString) MarshalText() (text []byte, err error) {
 	if !fs.Valid {
-		// TODO Consider marshalling as empty value instead?
-		return text, errors.Errorf("invalid ft.NString")
+		return []byte{}, nil
 	}
 	return []byte(fs.String), nil
 }
 
 func (fs *NString) UnmarshalText(text []byte) error {
-	return fs.UnmarshalJSON(text)
+	return fs.unmarshalText(text, &defaultPolicy)
+}
+
+func (fs *NString) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, true)
+	return fs.unmarshalJSON(bArr, tp)
 }
 
 // NInt can be used to decode any JSON value to int64.
 // Strings that are not valid representation of a number will error.
+// The fractional part of numbers is truncated, see Policy.FloatToInt.
 // Boolean values will error
 type NInt null.Int
 
@@ -128,65 +109,43 @@ func (fi NInt) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON method for Int
 func (fi *NInt) UnmarshalJSON(bArr []byte) (err error) {
-	s, i, f, b :=
-		string(""), int64(0), float64(0), false
-
-	// Value is null
-	if string(bArr) == "null" {
-		*fi = NInt(null.Int{})
-		return
-	}
-
-	// Value is a...
-	// string
-	if err = json.Unmarshal(bArr, &s); err == nil {
-		if strings.TrimSpace(s) == "" {
-			// Empty string parses as null
-			*fi = NInt(null.Int{})
-			return
-		}
-		i, err2 := strconv.ParseInt(s, 10, 64)
-		if err2 != nil {
-			return err2
-		}
-		*fi = NInt(null.IntFrom(i))
-		return
-	}
+	return fi.unmarshalJSON(bArr, &defaultPolicy)
+}
 
-	// int
-	if err = json.Unmarshal(bArr, &i); err == nil {
-		*fi = NInt(null.IntFrom(i))
-		return
+func (fi *NInt) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	i, valid, err := coerceInt(bArr, p, true)
+	if err != nil {
+		return err
 	}
-
-	// float
-	if err = json.Unmarshal(bArr, &f); err == nil {
-		*fi = NInt(null.IntFrom(int64(f)))
+	if !valid {
+		*fi = NInt(null.Int{})
 		return
 	}
-
-	// bool
-	if err = json.Unmarshal(bArr, &b); err == nil {
-		return errors.Errorf("value is a bool")
-	}
-
+	*fi = NInt(null.IntFrom(i))
 	return
 }
 
 func (fi NInt) MarshalText() (text []byte, err error) {
 	if !fi.Valid {
-		return text, errors.Errorf("invalid ft.NInt")
+		return []byte{}, nil
 	}
 	return []byte(strconv.FormatInt(fi.Int64, 10)), nil
 }
 
 func (fi *NInt) UnmarshalText(text []byte) error {
-	return fi.UnmarshalJSON(text)
+	return fi.unmarshalText(text, &defaultPolicy)
+}
+
+func (fi *NInt) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, true)
+	return fi.unmarshalJSON(bArr, tp)
 }
 
 // NFloat can be used to decode any JSON value to int64.
 // Strings that are not valid representation of a number will error.
-// Boolean values will error
+// Boolean values will error.
+// Strings like "NaN" and "-Infinity" are decoded as per Policy.NonFinite,
+// non-finite values are encoded as JSON strings
 type NFloat null.Float
 
 func NFloatFrom(ff float64) NFloat {
@@ -198,65 +157,47 @@ func (fi NFloat) MarshalJSON() ([]byte, error) {
 	if !fi.Valid {
 		return []byte(`null`), nil
 	}
-	return []byte(strconv.FormatFloat(fi.Float64, 'f', -1, 64)), nil
+	return marshalFloat(fi.Float64), nil
 }
 
 // UnmarshalJSON method for Float
 func (fi *NFloat) UnmarshalJSON(bArr []byte) (err error) {
-	s, i, f, b :=
-		string(""), int64(0), float64(0), false
-
-	// Value is null
-	if string(bArr) == "null" {
-		*fi = NFloat(null.Float{})
-		return
-	}
-
-	// Value is a...
-	// string
-	if err = json.Unmarshal(bArr, &s); err == nil {
-		i, err2 := strconv.ParseFloat(s, 64)
-		if err2 != nil {
-			return err2
-		}
-		*fi = NFloat(null.FloatFrom(i))
-		return
-	}
+	return fi.unmarshalJSON(bArr, &defaultPolicy)
+}
 
-	// int
-	if err = json.Unmarshal(bArr, &i); err == nil {
-		*fi = NFloat(null.FloatFrom(float64(i)))
-		return
+func (fi *NFloat) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	f, valid, err := coerceFloat(bArr, p, true)
+	if err != nil {
+		return err
 	}
-
-	// float
-	if err = json.Unmarshal(bArr, &f); err == nil {
-		*fi = NFloat(null.FloatFrom(f))
+	if !valid {
+		*fi = NFloat(null.Float{})
 		return
 	}
-
-	// bool
-	if err = json.Unmarshal(bArr, &b); err == nil {
-		return errors.Errorf("value is a bool")
-	}
-
+	*fi = NFloat(null.FloatFrom(f))
 	return
 }
 
 func (ff NFloat) MarshalText() (text []byte, err error) {
 	if !ff.Valid {
-		return text, errors.Errorf("invalid ft.NFloat")
+		return []byte{}, nil
 	}
-	return []byte(strconv.FormatFloat(ff.Float64, 'f', -1, 64)), nil
+	return []byte(formatFloat(ff.Float64)), nil
 }
 
 func (ff *NFloat) UnmarshalText(text []byte) error {
-	return ff.UnmarshalJSON(text)
+	return ff.unmarshalText(text, &defaultPolicy)
+}
+
+func (ff *NFloat) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, true)
+	return ff.unmarshalJSON(bArr, tp)
 }
 
 // NBool can be used to decode any JSON value to bool.
-// Empty strings as well as "false" and "0" evaluate to false,
-// all other strings are true.
+// Strings like "true", "yes" and "on" evaluate to true,
+// and "false", "no", "off" and "" evaluate to false.
+// Other strings will error, see Policy.BoolStrings.
 // Numbers equal to 0 will evaluate to false,
 // all other numbers are true.
 type NBool null.Bool
@@ -275,63 +216,34 @@ func (fb NBool) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON method for Bool
 func (fb *NBool) UnmarshalJSON(bArr []byte) (err error) {
-	s, i, f, b :=
-		string(""), int64(0), float64(0), false
-
-	// Value is null
-	if string(bArr) == "null" {
-		*fb = NBool(null.Bool{})
-		return
-	}
-
-	// Value is a...
-	// string
-	if err = json.Unmarshal(bArr, &s); err == nil {
-		compare := strings.ToLower(strings.TrimSpace(s))
-		if compare == "false" || compare == "0" || compare == "" {
-			*fb = NBool(null.BoolFrom(false))
-			return
-		}
-		*fb = NBool(null.BoolFrom(true))
-		return
-	}
-
-	// int
-	if err = json.Unmarshal(bArr, &i); err == nil {
-		if i == 0 {
-			*fb = NBool(null.BoolFrom(false))
-			return
-		}
-		*fb = NBool(null.BoolFrom(true))
-		return
-	}
+	return fb.unmarshalJSON(bArr, &defaultPolicy)
+}
 
-	// float
-	if err = json.Unmarshal(bArr, &f); err == nil {
-		if f == 0 {
-			*fb = NBool(null.BoolFrom(false))
-			return
-		}
-		*fb = NBool(null.BoolFrom(true))
-		return
+func (fb *NBool) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	b, valid, err := coerceBool(bArr, p, true)
+	if err != nil {
+		return err
 	}
-
-	// bool
-	if err = json.Unmarshal(bArr, &b); err == nil {
-		*fb = NBool(null.BoolFrom(b))
+	if !valid {
+		*fb = NBool(null.Bool{})
 		return
 	}
-
+	*fb = NBool(null.BoolFrom(b))
 	return
 }
 
 func (fb NBool) MarshalText() (text []byte, err error) {
 	if !fb.Valid {
-		return text, errors.Errorf("invalid ft.NBool")
+		return []byte{}, nil
 	}
 	return []byte(strconv.FormatBool(fb.Bool)), nil
 }
 
 func (fb *NBool) UnmarshalText(text []byte) error {
-	return fb.UnmarshalJSON(text)
+	return fb.unmarshalText(text, &defaultPolicy)
+}
+
+func (fb *NBool) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, true)
+	return fb.unmarshalJSON(bArr, tp)
 }
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"testing"
 	"text/template"
 	"unicode"
@@ -105,7 +104,7 @@ func TestMarshalNString(t *testing.T) {
 	d.String = ft.NStringFrom("foo\u0002bar")
 	b, err = json.Marshal(d)
 	is.NoErr(err)
-	is.Equal(`{"string":"foobar"}`, string(b))
+	is.Equal(`{"string":"foo\u0002bar"}`, string(b))
 
 	// Slashes
 	d.String = ft.NStringFrom("bla bla\\bla bla")
@@ -332,12 +331,22 @@ func TestUnmarshalNBool(t *testing.T) {
 	is.Equal(true, d.Bool.Valid) // Bool must be valid
 	is.Equal(true, d.Bool.Bool)  // Value must match
 
-	b = []byte(`{"bool": "abc"}`)
+	b = []byte(`{"bool": "N"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(true, d.Bool.Valid) // Bool must be valid
+	is.Equal(false, d.Bool.Bool) // Value must match
+
+	b = []byte(`{"bool": "Enabled"}`)
 	err = json.Unmarshal(b, &d)
 	is.NoErr(err)
 	is.Equal(true, d.Bool.Valid) // Bool must be valid
 	is.Equal(true, d.Bool.Bool)  // Value must match
 
+	b = []byte(`{"bool": "abc"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal(`value "abc" is not a recognised bool`, err.Error())
+
 	// int
 	b = []byte(`{"bool": 0}`)
 	err = json.Unmarshal(b, &d)
@@ -558,34 +567,23 @@ func TestNMapKeys(t *testing.T) {
 
 	is.Equal(string(b), `{"StringMap":{"foo":true},"IntMap":{"123":true},"BoolMap":{"true":true},"FloatMap":{"1.618":true}}`)
 
-	wrap := func(s1, s2 string) string {
-		return fmt.Sprintf(
-			`json: encoding error for type "map[ft.%s]bool": "%s"`, s1, s2)
+	// Null map keys are empty text, and empty text is null
+	d = data{
+		StringMap: map[ft.NString]bool{{}: true},
+		IntMap:    map[ft.NInt]bool{{}: true},
+		BoolMap:   map[ft.NBool]bool{{}: true},
+		FloatMap:  map[ft.NFloat]bool{{}: true},
 	}
-
-	d = data{StringMap: make(map[ft.NString]bool)}
-	d.StringMap[ft.NString{sql.NullString{String: "", Valid: false}}] = true
-	_, err = json.Marshal(d)
-	is.Equal(err.Error(),
-		wrap("NString", "invalid ft.NString")) // Map keys must be valid
-
-	d = data{IntMap: make(map[ft.NInt]bool)}
-	d.IntMap[ft.NInt{sql.NullInt64{Int64: 0, Valid: false}}] = true
-	_, err = json.Marshal(d)
-	is.Equal(err.Error(),
-		wrap("NInt", "invalid ft.NInt")) // Map keys must be valid
-
-	d = data{FloatMap: make(map[ft.NFloat]bool)}
-	d.FloatMap[ft.NFloat{sql.NullFloat64{Float64: 0, Valid: false}}] = true
-	_, err = json.Marshal(d)
-	is.Equal(err.Error(),
-		wrap("NFloat", "invalid ft.NFloat")) // Map keys must be valid
-
-	d = data{BoolMap: make(map[ft.NBool]bool)}
-	d.BoolMap[ft.NBool{sql.NullBool{Bool: false, Valid: false}}] = true
-	_, err = json.Marshal(d)
-	is.Equal(err.Error(),
-		wrap("NBool", "invalid ft.NBool")) // Map keys must be valid
+	b, err = json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(string(b), `{"StringMap":{"":true},"IntMap":{"":true},"BoolMap":{"":true},"FloatMap":{"":true}}`)
+	d = data{}
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.True(d.StringMap[ft.NString{}]) // Key must be null
+	is.True(d.IntMap[ft.NInt{}])       // Key must be null
+	is.True(d.BoolMap[ft.NBool{}])     // Key must be null
+	is.True(d.FloatMap[ft.NFloat{}])   // Key must be null
 
 	b = []byte(`{"StringMap":{"foo":true},"IntMap":{"123":true},"BoolMap":{"true":true},"FloatMap":{"1.618":true}}`)
 	d = data{}
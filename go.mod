@@ -6,4 +6,5 @@ require (
 	github.com/guregu/null v4.0.0+incompatible
 	github.com/matryer/is v1.4.1
 	github.com/pkg/errors v0.9.1
+	golang.org/x/text v0.22.0
 )
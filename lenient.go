@@ -0,0 +1,462 @@
+package ft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Lenient is a bit set of relaxations of the JSON grammar, for input
+// written by hand or by clients that emit JSON5-style documents.
+// The input is rewritten as strict JSON before it is decoded,
+// see NewLenientReader, NewLenientDecoder and UnmarshalLenient
+type Lenient uint8
+
+const (
+	// LenientComments allows line and block comments,
+	// e.g. // comment and /* comment */
+	LenientComments Lenient = 1 << iota
+	// LenientTrailingCommas allows a comma after the last element
+	// of an object or array, e.g. [1, 2,]
+	LenientTrailingCommas
+	// LenientSingleQuotes allows strings in single quotes, e.g. 'it\'s'
+	LenientSingleQuotes
+	// LenientUnquotedKeys allows object keys that are identifiers
+	// without quotes, e.g. {name: "foo"}
+	LenientUnquotedKeys
+	// LenientNonFinite allows the bare values NaN, Infinity and
+	// -Infinity, they are decoded like the strings "NaN", "Infinity" and
+	// "-Infinity", see Policy.NonFinite
+	LenientNonFinite
+)
+
+// LenientAll enables all the relaxations
+const LenientAll = LenientComments | LenientTrailingCommas |
+	LenientSingleQuotes | LenientUnquotedKeys | LenientNonFinite
+
+// LenientReader rewrites lenient JSON read from r as strict JSON.
+// Comments and trailing commas are replaced with white space,
+// so byte offsets only change after quotes are added or removed,
+// use InputOffset to map offsets back to the input
+type LenientReader struct {
+	r   io.Reader
+	s   lenientScanner
+	buf []byte
+	err error
+}
+
+// NewLenientReader returns a reader that rewrites the lenient JSON
+// read from r as strict JSON
+func NewLenientReader(r io.Reader, l Lenient) *LenientReader {
+	return &LenientReader{r: r, s: lenientScanner{l: l}}
+}
+
+// Read reads strict JSON into b
+func (lr *LenientReader) Read(b []byte) (n int, err error) {
+	for len(lr.s.out) == 0 && lr.err == nil {
+		if lr.buf == nil {
+			lr.buf = make([]byte, 4096)
+		}
+		n, err = lr.r.Read(lr.buf)
+		lr.s.write(lr.buf[:n])
+		if err == io.EOF {
+			lr.s.close()
+		}
+		if lr.s.err != nil {
+			err = lr.s.err
+		}
+		lr.err = err
+	}
+	if len(lr.s.out) > 0 {
+		n = copy(b, lr.s.out)
+		lr.s.out = lr.s.out[n:]
+		return n, nil
+	}
+	return 0, lr.err
+}
+
+// InputOffset returns the offset in the input for
+// the offset in the strict JSON, e.g. of a json.SyntaxError
+func (lr *LenientReader) InputOffset(offset int64) int64 {
+	return lr.s.inputOffset(offset)
+}
+
+// inputError returns err with offsets mapped to the input
+func (lr *LenientReader) inputError(err error) error {
+	se := &json.SyntaxError{}
+	if errors.As(err, &se) {
+		mapped := *se
+		mapped.Offset = lr.InputOffset(se.Offset)
+		return &mapped
+	}
+	return err
+}
+
+// valueError returns err, returned by the value at offset in the strict
+// JSON, with the offset of the value in the input. The Offset of a
+// json.UnmarshalTypeError is set, other errors are wrapped in an OffsetError
+func (lr *LenientReader) valueError(err error, offset int64) error {
+	offset = lr.InputOffset(offset)
+	te := &json.UnmarshalTypeError{}
+	if errors.As(err, &te) {
+		mapped := *te
+		mapped.Offset = offset
+		return &mapped
+	}
+	return &OffsetError{Offset: offset, Err: err}
+}
+
+// OffsetError is a decode error of lenient JSON,
+// with the offset in the input of the value that caused it
+type OffsetError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *OffsetError) Error() string {
+	return fmt.Sprintf("%v at offset %d", e.Err, e.Offset)
+}
+
+func (e *OffsetError) Unwrap() error {
+	return e.Err
+}
+
+// NewLenientDecoder returns a decoder that reads lenient JSON from r,
+// the offsets of errors are offsets in the input
+func NewLenientDecoder(r io.Reader, l Lenient) *Decoder {
+	lr := NewLenientReader(r, l)
+	d := NewDecoder(lr)
+	d.lenient = lr
+	return d
+}
+
+// UnmarshalLenient is like Unmarshal for lenient JSON
+func UnmarshalLenient(data []byte, v interface{}, p Policy, l Lenient) error {
+	lr := &LenientReader{s: lenientScanner{l: l}}
+	lr.s.write(data)
+	lr.s.close()
+	if lr.s.err != nil {
+		return lr.s.err
+	}
+	raw := json.RawMessage{}
+	if err := json.Unmarshal(lr.s.out, &raw); err != nil {
+		return lr.inputError(err)
+	}
+	ds := newDecodeState()
+	err := ds.unmarshal(raw, v, &p)
+	if err != nil && ds.errOffset >= 0 {
+		// raw starts after the leading white space of the output
+		start := len(lr.s.out) - len(bytes.TrimLeft(lr.s.out, jsonSpace))
+		return lr.valueError(err, int64(start)+ds.errOffset)
+	}
+	return err
+}
+
+// lenientState is the state of the lenientScanner
+type lenientState uint8
+
+const (
+	lenientValue lenientState = iota
+	lenientWord
+	lenientString
+	lenientSingle
+	lenientSlash
+	lenientLine
+	lenientBlock
+	lenientBlockStar
+)
+
+// lenientShift marks the output offset from where
+// offsets in the output and the input differ by the same amount
+type lenientShift struct {
+	out, in int64
+}
+
+// lenientScanner rewrites lenient JSON one byte at a time
+type lenientScanner struct {
+	l     Lenient
+	state lenientState
+	// escape is set after a backslash in a string
+	escape bool
+	// stack of the open objects and arrays
+	stack []byte
+	// expectKey is set if the next string or word is an object key
+	expectKey bool
+	isKey     bool
+	word      []byte
+	wordIn    int64
+	// pending holds a comma, and the white space and comments after it,
+	// until the next token shows if it is a trailing comma
+	pending   []byte
+	pendingIn int64
+	// commentIn is the input offset of the current comment
+	commentIn int64
+	// in and n are the number of bytes read and written
+	in, n  int64
+	shifts []lenientShift
+	out    []byte
+	err    error
+}
+
+// emit writes b for the input at offset in
+func (s *lenientScanner) emit(in int64, b ...byte) {
+	delta := int64(0)
+	if len(s.shifts) > 0 {
+		last := s.shifts[len(s.shifts)-1]
+		delta = last.out - last.in
+	}
+	if s.n-in != delta {
+		s.shifts = append(s.shifts, lenientShift{out: s.n, in: in})
+	}
+	s.out = append(s.out, b...)
+	s.n += int64(len(b))
+}
+
+// space writes white space for the byte c at offset in that is removed,
+// newlines are kept. Comments after a comma are held with the comma
+func (s *lenientScanner) space(in int64, c byte) {
+	if c != '\n' {
+		c = ' '
+	}
+	if s.pending != nil {
+		s.pending = append(s.pending, c)
+		return
+	}
+	s.emit(in, c)
+}
+
+func (s *lenientScanner) inputOffset(offset int64) int64 {
+	i := sort.Search(len(s.shifts), func(i int) bool {
+		return s.shifts[i].out > offset
+	})
+	if i == 0 {
+		return offset
+	}
+	shift := s.shifts[i-1]
+	return offset - shift.out + shift.in
+}
+
+func (s *lenientScanner) write(data []byte) {
+	for _, c := range data {
+		if s.err != nil {
+			return
+		}
+		s.step(c)
+		s.in++
+	}
+}
+
+// close flushes the output at the end of the input
+func (s *lenientScanner) close() {
+	switch s.state {
+	case lenientWord:
+		s.endWord()
+	case lenientSlash:
+		s.emit(s.in-1, '/')
+	case lenientBlock, lenientBlockStar:
+		s.err = errors.Errorf(
+			"comment at offset %d is not terminated", s.commentIn)
+	}
+	s.state = lenientValue
+	s.flush(false)
+}
+
+// flush writes the pending comma, or white space if it is trailing
+func (s *lenientScanner) flush(trailing bool) {
+	if s.pending == nil {
+		return
+	}
+	if trailing {
+		s.pending[0] = ' '
+	}
+	pending := s.pending
+	s.pending = nil
+	s.emit(s.pendingIn, pending...)
+}
+
+func (s *lenientScanner) step(c byte) {
+	switch s.state {
+	case lenientWord:
+		if isWordByte(c) {
+			s.word = append(s.word, c)
+			return
+		}
+		s.endWord()
+		s.value(c)
+
+	case lenientString:
+		s.emit(s.in, c)
+		switch {
+		case s.escape:
+			s.escape = false
+		case c == '\\':
+			s.escape = true
+		case c == '"':
+			s.state = lenientValue
+		}
+
+	case lenientSingle:
+		switch {
+		case s.escape:
+			s.escape = false
+			if c == '\'' {
+				s.emit(s.in-1, c)
+			} else {
+				s.emit(s.in-1, '\\', c)
+			}
+		case c == '\\':
+			s.escape = true
+		case c == '"':
+			s.emit(s.in, '\\', c)
+		case c == '\'':
+			s.emit(s.in, '"')
+			s.state = lenientValue
+		default:
+			s.emit(s.in, c)
+		}
+
+	case lenientSlash:
+		switch c {
+		case '/':
+			s.state = lenientLine
+		case '*':
+			s.state = lenientBlock
+		default:
+			// Not a comment
+			s.flush(false)
+			s.emit(s.in-1, '/')
+			s.state = lenientValue
+			s.value(c)
+			return
+		}
+		s.space(s.in-1, '/')
+		s.space(s.in, c)
+
+	case lenientLine:
+		if c == '\n' {
+			s.state = lenientValue
+		}
+		s.space(s.in, c)
+
+	case lenientBlock, lenientBlockStar:
+		switch {
+		case c == '*':
+			s.state = lenientBlockStar
+		case c == '/' && s.state == lenientBlockStar:
+			s.state = lenientValue
+		default:
+			s.state = lenientBlock
+		}
+		s.space(s.in, c)
+
+	default:
+		s.value(c)
+	}
+}
+
+// value handles c outside of strings, words and comments
+func (s *lenientScanner) value(c byte) {
+	if c == '/' && s.l&LenientComments != 0 {
+		s.state = lenientSlash
+		s.commentIn = s.in
+		return
+	}
+	if s.pending != nil {
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			s.pending = append(s.pending, c)
+			return
+		}
+		s.flush((c == '}' || c == ']') && s.l&LenientTrailingCommas != 0)
+	}
+
+	s.isKey = s.expectKey
+	switch {
+	case c == ',':
+		s.pending = []byte{c}
+		s.pendingIn = s.in
+		s.expectKey = s.inObject()
+		return
+	case c == '{':
+		s.stack = append(s.stack, c)
+		s.expectKey = true
+	case c == '[':
+		s.stack = append(s.stack, c)
+		s.expectKey = false
+	case c == '}' || c == ']':
+		if len(s.stack) > 0 {
+			s.stack = s.stack[:len(s.stack)-1]
+		}
+		s.expectKey = false
+	case c == '"':
+		s.state = lenientString
+		s.expectKey = false
+	case c == '\'' && s.l&LenientSingleQuotes != 0:
+		s.state = lenientSingle
+		s.expectKey = false
+		s.emit(s.in, '"')
+		return
+	case isWordByte(c):
+		s.state = lenientWord
+		s.expectKey = false
+		s.word = append(s.word[:0], c)
+		s.wordIn = s.in
+		return
+	case c == ':':
+		s.expectKey = false
+	}
+	s.emit(s.in, c)
+}
+
+func (s *lenientScanner) inObject() bool {
+	return len(s.stack) > 0 && s.stack[len(s.stack)-1] == '{'
+}
+
+// endWord writes the word, e.g. a number, literal or unquoted key
+func (s *lenientScanner) endWord() {
+	s.state = lenientValue
+	w := string(s.word)
+	switch {
+	case s.isKey && s.l&LenientUnquotedKeys != 0 && isIdentifier(w),
+		s.l&LenientNonFinite != 0 && isNonFiniteWord(w):
+		s.emit(s.wordIn, '"')
+		s.emit(s.wordIn, s.word...)
+		s.emit(s.wordIn+int64(len(w)), '"')
+	default:
+		s.emit(s.wordIn, s.word...)
+	}
+}
+
+// isWordByte returns true for bytes of numbers, literals and identifiers
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' || c == '_' || c == '$' ||
+		c == '-' || c == '+' || c == '.' || c >= utf8.RuneSelf
+}
+
+// isIdentifier returns true if s is an ECMAScript identifier,
+// e.g. name, _id or $ref
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if r == '_' || r == '$' || unicode.IsLetter(r) ||
+			(i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return s != ""
+}
+
+// isNonFiniteWord returns true for the bare values NaN and Infinity
+func isNonFiniteWord(s string) bool {
+	switch s {
+	case "NaN", "Infinity", "+Infinity", "-Infinity":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package ft_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestLenientReader(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		lenient ft.Lenient
+		input   string
+		want    string
+	}{
+		{ft.LenientComments, "[1, // one\n2]", "[1,       \n2]"},
+		{ft.LenientComments, `[1, /* one */ 2]`, `[1,           2]`},
+		{ft.LenientComments, `{"url": "http://x"}`, `{"url": "http://x"}`},
+		{ft.LenientTrailingCommas, `[1, 2,]`, `[1, 2 ]`},
+		{ft.LenientTrailingCommas, "{\"a\": 1,\n}", "{\"a\": 1 \n}"},
+		{ft.LenientTrailingCommas, `["a,]", 2]`, `["a,]", 2]`},
+		{ft.LenientTrailingCommas | ft.LenientComments,
+			`[1, /* two */ ]`, `[1            ]`},
+		{ft.LenientSingleQuotes, `['foo']`, `["foo"]`},
+		{ft.LenientSingleQuotes, `['it\'s "x"']`, `["it's \"x\""]`},
+		{ft.LenientSingleQuotes, `['a\nb']`, `["a\nb"]`},
+		{ft.LenientUnquotedKeys, `{name: "foo", _id: 1}`,
+			`{"name": "foo", "_id": 1}`},
+		{ft.LenientUnquotedKeys, `{"a": true, b: null}`,
+			`{"a": true, "b": null}`},
+		{ft.LenientUnquotedKeys, `[name]`, `[name]`},
+		{ft.LenientNonFinite, `[NaN, -Infinity, 1e5]`,
+			`["NaN", "-Infinity", 1e5]`},
+		{ft.LenientAll, `{a: 'x', /* c */ b: [Infinity,],}`,
+			`{"a": "x",         "b": ["Infinity" ] }`},
+	}
+	for _, tt := range tests {
+		b, err := io.ReadAll(
+			ft.NewLenientReader(strings.NewReader(tt.input), tt.lenient))
+		is.NoErr(err)
+		is.Equal(tt.want, string(b)) // Output must match
+	}
+
+	// Each relaxation is separate
+	for _, input := range []string{
+		`[1, // one` + "\n" + `2]`, `[1, 2,]`, `['foo']`, `{name: "foo"}`,
+		`[NaN]`,
+	} {
+		b, err := io.ReadAll(ft.NewLenientReader(strings.NewReader(input), 0))
+		is.NoErr(err)
+		is.Equal(input, string(b)) // Input must not change
+	}
+
+	_, err := io.ReadAll(ft.NewLenientReader(
+		strings.NewReader(`[1 /* one`), ft.LenientComments))
+	is.Equal("comment at offset 3 is not terminated", err.Error())
+}
+
+func TestUnmarshalLenient(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Name  ft.String `json:"name"`
+		Qty   ft.Int    `json:"qty"`
+		Ratio ft.Float  `json:"ratio"`
+	}
+	d := Data{}
+	err := ft.UnmarshalLenient([]byte(`{
+		// Hand-written
+		name: 'foo',
+		qty: "3",
+		ratio: NaN,
+	}`), &d, ft.Policy{}, ft.LenientAll)
+	is.NoErr(err)
+	is.Equal("foo", d.Name.String)       // Value must match
+	is.Equal(int64(3), d.Qty.Int64)      // Value must match
+	is.True(math.IsNaN(d.Ratio.Float64)) // Value must be NaN
+
+	// Syntax errors have offsets in the input
+	err = ft.UnmarshalLenient([]byte(`{name: 'foo', qty: 1 2}`), &d,
+		ft.Policy{}, ft.LenientAll)
+	se := &json.SyntaxError{}
+	is.True(errors.As(err, &se))
+	is.Equal(int64(22), se.Offset) // Offset must match
+
+	err = ft.UnmarshalLenient([]byte(`[1,]`), &[]ft.Int{}, ft.Policy{},
+		ft.LenientComments)
+	is.True(err != nil) // Trailing commas must be enabled
+
+	// Decode errors have the offset of the value in the input
+	err = ft.UnmarshalLenient([]byte(`[[1], /* two */ {a: 2}]`),
+		&[][]ft.Int{}, ft.Policy{}, ft.LenientAll)
+	te := &json.UnmarshalTypeError{}
+	is.True(errors.As(err, &te))
+	is.Equal(int64(16), te.Offset) // Offset must match
+	err = ft.UnmarshalLenient([]byte(` {'name': 'foo', qty: 2.5}`), &d,
+		ft.Policy{FloatToInt: ft.FloatToIntReject}, ft.LenientAll)
+	oe := &ft.OffsetError{}
+	is.True(errors.As(err, &oe))
+	is.Equal(int64(22), oe.Offset) // Offset must match
+	is.Equal("value 2.5 has a fractional part at offset 22", err.Error())
+
+	// Decoder
+	dec := ft.NewLenientDecoder(strings.NewReader(
+		"{qty: 1,} /* next */ {qty: '2'}"), ft.LenientAll)
+	for _, want := range []int64{1, 2} {
+		d = Data{}
+		is.NoErr(dec.Decode(&d))
+		is.Equal(want, d.Qty.Int64) // Value must match
+	}
+	dec = ft.NewLenientDecoder(strings.NewReader(`{qty: 'x' 'y'}`),
+		ft.LenientAll)
+	err = dec.Decode(&d)
+	is.True(errors.As(err, &se))
+	is.Equal(int64(11), se.Offset) // Offset must match
+	dec = ft.NewLenientDecoder(strings.NewReader(
+		"{qty: 1} {'qty': 'x'}"), ft.LenientAll)
+	is.NoErr(dec.Decode(&d))
+	err = dec.Decode(&d)
+	is.True(errors.As(err, &oe))
+	is.Equal(int64(17), oe.Offset) // Offset must match
+}
@@ -0,0 +1,236 @@
+package ft
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Locale describes the separators used in numeric strings, e.g. "1.234,56".
+// Set Policy.Locale to decode numeric strings formatted for the locale.
+// Unicode minus signs, full-width and other decimal digits, and
+// non-breaking spaces are accepted in any locale. Currency symbols are
+// accepted before or after the number, e.g. "-€ 9,99" or "9,99 €".
+// Strings that are JSON numbers, e.g. "1e3", are decoded as such,
+// unless they have a "." and the decimal separator is not "."
+// Groups must have 3 digits, or 2 digits for Indian grouping,
+// e.g. "12,34,567.00". Strings that are not valid for the locale will error
+type Locale struct {
+	// Name is used in error messages and by the locale struct tag directive
+	Name string
+	// Decimal is the decimal separator. Zero means the separators are
+	// inferred from the value, ambiguous values like "1,234" will error
+	Decimal rune
+	// Group are the group separators, a space matches any Unicode space
+	Group []rune
+}
+
+var (
+	// LocaleEN formats numbers like 1,234.56
+	LocaleEN = &Locale{Name: "en", Decimal: '.', Group: []rune{','}}
+	// LocaleDE formats numbers like 1.234,56 or 1 234,56
+	LocaleDE = &Locale{Name: "de", Decimal: ',', Group: []rune{'.', ' '}}
+	// LocaleFR formats numbers like 1 234,56
+	LocaleFR = &Locale{Name: "fr", Decimal: ',', Group: []rune{' '}}
+	// LocaleCH formats numbers like 1'234.56
+	LocaleCH = &Locale{Name: "ch", Decimal: '.', Group: []rune{'\''}}
+	// LocaleAuto infers the separators from the value
+	LocaleAuto = &Locale{Name: "auto"}
+)
+
+// localeNames are used by the locale struct tag directive
+var localeNames = map[string]*Locale{
+	LocaleEN.Name:   LocaleEN,
+	LocaleDE.Name:   LocaleDE,
+	LocaleFR.Name:   LocaleFR,
+	LocaleCH.Name:   LocaleCH,
+	LocaleAuto.Name: LocaleAuto,
+}
+
+// zeroDigits are the zero code points of the decimal digit ranges
+// that are mapped to ASCII digits
+var zeroDigits = []rune{
+	0x0660, // Arabic-Indic
+	0x06F0, // Extended Arabic-Indic
+	0x0966, // Devanagari
+	0xFF10, // Full-width
+}
+
+// localeRune maps r to ASCII where possible
+func localeRune(r rune) rune {
+	for _, zero := range zeroDigits {
+		if r >= zero && r <= zero+9 {
+			return '0' + r - zero
+		}
+	}
+	switch r {
+	case '−', '﹣', '－': // Minus signs
+		return '-'
+	case '＋':
+		return '+'
+	case '．':
+		return '.'
+	case '，':
+		return ','
+	case '’', '＇':
+		return '\''
+	}
+	if unicode.IsSpace(r) {
+		return ' '
+	}
+	return r
+}
+
+// isCurrencyOrSpace returns true for currency symbols and spaces
+func isCurrencyOrSpace(r rune) bool {
+	return r == ' ' || unicode.Is(unicode.Sc, r)
+}
+
+// trimSign removes a leading sign from n, that is "-", "+" or empty
+func trimSign(n string) (string, string) {
+	if strings.HasPrefix(n, "-") || strings.HasPrefix(n, "+") {
+		return strings.TrimSpace(n[1:]), n[:1]
+	}
+	return n, ""
+}
+
+// normalise returns s as a number that can be parsed by strconv
+func (l *Locale) normalise(s string) (string, error) {
+	invalid := errors.Errorf("value %q is not a number in locale %s", s, l.Name)
+
+	// The sign may be before or after a leading currency symbol,
+	// e.g. "-€5" or "€-5", currency symbols may also follow the number
+	n, sign := trimSign(strings.TrimSpace(strings.Map(localeRune, s)))
+	n = strings.TrimLeftFunc(n, isCurrencyOrSpace)
+	if sign == "" {
+		n, sign = trimSign(n)
+	}
+	n = strings.TrimRightFunc(n, isCurrencyOrSpace)
+	if sign == "+" {
+		sign = ""
+	}
+	if n == "" {
+		return s, invalid
+	}
+
+	decimal, group := l.Decimal, l.Group
+	if decimal == 0 {
+		var err error
+		if decimal, group, err = inferSeparators(s, n); err != nil {
+			return s, err
+		}
+	}
+
+	whole, frac := n, ""
+	if i := strings.IndexRune(n, decimal); i >= 0 {
+		whole, frac = n[:i], n[i+len(string(decimal)):]
+		if whole == "" || frac == "" || !isDigits(frac) {
+			return s, invalid
+		}
+	}
+
+	// Split the whole part into groups, only one kind of separator is used
+	sep := rune(0)
+	groups := strings.FieldsFunc(whole, func(r rune) bool {
+		if r >= '0' && r <= '9' {
+			return false
+		}
+		if sep == 0 && containsRune(group, r) {
+			sep = r
+		}
+		return r == sep
+	})
+	if len(groups) == 0 || !validGroups(groups) ||
+		strings.Count(whole, string(sep)) != len(groups)-1 {
+		return s, invalid
+	}
+
+	n = sign + strings.Join(groups, "")
+	if frac != "" {
+		n += "." + frac
+	}
+	return n, nil
+}
+
+// inferSeparators returns the separators for n, the normalised form of s.
+// The last of "." and "," is the decimal separator if both are used,
+// or the separator is repeated, or other group separators are used.
+// Otherwise a single separator followed by 3 digits is ambiguous
+func inferSeparators(s, n string) (decimal rune, group []rune, err error) {
+	group = []rune{' ', '\''}
+	dots, commas := strings.Count(n, "."), strings.Count(n, ",")
+	switch {
+	case dots == 0 && commas == 0:
+		return '.', group, nil
+	case dots > 0 && commas > 0:
+		if strings.LastIndex(n, ".") > strings.LastIndex(n, ",") {
+			return '.', append(group, ','), nil
+		}
+		return ',', append(group, '.'), nil
+	case dots > 1:
+		return ',', append(group, '.'), nil
+	case commas > 1:
+		return '.', append(group, ','), nil
+	}
+
+	sep := '.'
+	other := ','
+	if commas == 1 {
+		sep, other = ',', '.'
+	}
+	i := strings.IndexRune(n, sep)
+	before, after := n[:i], n[i+1:]
+	if len(after) == 3 && isDigits(after) &&
+		len(before) <= 3 && isDigits(before) && !strings.HasPrefix(before, "0") {
+		return 0, nil, errors.Errorf(
+			"value %q is ambiguous, %q may be a decimal or group separator",
+			s, string(sep))
+	}
+	return sep, append(group, other), nil
+}
+
+// validGroups returns true if the groups have 3 digits,
+// or 2 digits for Indian grouping, the first group may be shorter
+func validGroups(groups []string) bool {
+	for _, g := range groups {
+		if g == "" || !isDigits(g) {
+			return false
+		}
+	}
+	if len(groups) == 1 {
+		return true
+	}
+	size := 3
+	if len(groups) > 2 && len(groups[1]) == 2 {
+		size = 2
+	}
+	if len(groups[0]) > size || len(groups[len(groups)-1]) != 3 {
+		return false
+	}
+	for _, g := range groups[1 : len(groups)-1] {
+		if len(g) != size {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigits returns true if s only contains ASCII digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func containsRune(list []rune, r rune) bool {
+	for _, item := range list {
+		if item == r {
+			return true
+		}
+	}
+	return false
+}
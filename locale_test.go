@@ -0,0 +1,132 @@
+package ft_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestLocale(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		locale *ft.Locale
+		input  string
+		want   float64
+		err    string
+	}{
+		{ft.LocaleEN, `"1,234.56"`, 1234.56, ""},
+		{ft.LocaleEN, `"1,234"`, 1234, ""},
+		{ft.LocaleEN, `"12,34,567.00"`, 1234567, ""},
+		{ft.LocaleEN, `"$ 1,000,000"`, 1000000, ""},
+		{ft.LocaleEN, `"−42.5"`, -42.5, ""},
+		{ft.LocaleEN, `"１，２３４．５"`, 1234.5, ""},
+		{ft.LocaleEN, `"१२,३४५"`, 12345, ""},
+		{ft.LocaleEN, `"1,23"`, 0,
+			`value "1,23" is not a number in locale en`},
+		{ft.LocaleEN, `"1,234,56"`, 0,
+			`value "1,234,56" is not a number in locale en`},
+		{ft.LocaleEN, `"1.234,56"`, 0,
+			`value "1.234,56" is not a number in locale en`},
+		{ft.LocaleDE, `"1.234,56"`, 1234.56, ""},
+		{ft.LocaleDE, `"1 234,56"`, 1234.56, ""},
+		{ft.LocaleDE, "\"1\u00a0234,56\"", 1234.56, ""},
+		{ft.LocaleDE, `"1,234"`, 1.234, ""},
+		{ft.LocaleDE, `"€ 9,99"`, 9.99, ""},
+		{ft.LocaleDE, `"-9,99 €"`, -9.99, ""},
+		{ft.LocaleDE, `"1.5"`, 0,
+			`value "1.5" is not a number in locale de`},
+		{ft.LocaleDE, `"1.234 567"`, 0,
+			`value "1.234 567" is not a number in locale de`},
+		{ft.LocaleFR, "\"1\u202f234,56\"", 1234.56, ""},
+		{ft.LocaleCH, `"1'234.56"`, 1234.56, ""},
+		{ft.LocaleCH, `"1’234.56"`, 1234.56, ""},
+		{ft.LocaleAuto, `"1.234,56"`, 1234.56, ""},
+		{ft.LocaleAuto, `"1,234.56"`, 1234.56, ""},
+		{ft.LocaleAuto, `"1 234,56"`, 1234.56, ""},
+		{ft.LocaleAuto, `"12,34,567.00"`, 1234567, ""},
+		{ft.LocaleAuto, `"1.234.567"`, 1234567, ""},
+		{ft.LocaleAuto, `"€ 9,99"`, 9.99, ""},
+		{ft.LocaleAuto, `"0,123"`, 0.123, ""},
+		{ft.LocaleAuto, `"1234,567"`, 1234.567, ""},
+		{ft.LocaleAuto, `"1,234"`, 0,
+			`value "1,234" is ambiguous, "," may be a decimal or group separator`},
+		{ft.LocaleAuto, `"-1.234"`, 0,
+			`value "-1.234" is ambiguous, "." may be a decimal or group separator`},
+		{ft.LocaleAuto, `"abc"`, 0,
+			`value "abc" is not a number in locale auto`},
+		{ft.LocaleAuto, `"€"`, 0,
+			`value "€" is not a number in locale auto`},
+		// JSON numbers are tried first
+		{ft.LocaleEN, `"1e3"`, 1000, ""},
+		{ft.LocaleDE, `"1e3"`, 1000, ""},
+		{ft.LocaleFR, `"-2E-1"`, -0.2, ""},
+		{ft.LocaleCH, `"1.5e3"`, 1500, ""},
+		{ft.LocaleAuto, `"1e3"`, 1000, ""},
+		{ft.LocaleDE, `"1234"`, 1234, ""},
+		{ft.LocaleDE, `"1.234"`, 1234, ""},
+		// Currency symbols before or after the number
+		{ft.LocaleEN, `"-$5"`, -5, ""},
+		{ft.LocaleEN, `"$-5"`, -5, ""},
+		{ft.LocaleEN, `"5 USD"`, 0,
+			`value "5 USD" is not a number in locale en`},
+		{ft.LocaleEN, `"1€234"`, 0,
+			`value "1€234" is not a number in locale en`},
+		{ft.LocaleDE, `"1.234€,56"`, 0,
+			`value "1.234€,56" is not a number in locale de`},
+		{ft.LocaleEN, `"-$-5"`, 0,
+			`value "-$-5" is not a number in locale en`},
+	}
+	for _, tt := range tests {
+		p := ft.Policy{Locale: tt.locale}
+		f := ft.Float{}
+		err := ft.Unmarshal([]byte(tt.input), &f, p)
+		if tt.err != "" {
+			is.Equal(tt.err, err.Error()) // Error must match
+			continue
+		}
+		is.NoErr(err)
+		is.Equal(tt.want, f.Float64) // Value must match
+	}
+
+	// Numbers are not affected
+	f := ft.NFloat{}
+	err := ft.Unmarshal([]byte(`1.234`), &f, ft.Policy{Locale: ft.LocaleDE})
+	is.NoErr(err)
+	is.Equal(1.234, f.Float64) // Value must match
+
+	// Non-finite strings are decoded as per the NonFinite policy
+	err = ft.Unmarshal([]byte(`"-Infinity"`), &f, ft.Policy{Locale: ft.LocaleDE})
+	is.NoErr(err)
+	is.True(math.IsInf(f.Float64, -1)) // Value must be infinite
+	err = ft.Unmarshal([]byte(`"NaN"`), &f, ft.Policy{
+		Locale: ft.LocaleDE, NonFinite: ft.NonFiniteReject})
+	is.Equal(`value "NaN" is not a finite number`, err.Error())
+
+	// Integers
+	i := ft.NInt{}
+	err = ft.Unmarshal([]byte(`"0x1F"`), &i, ft.Policy{
+		Locale: ft.LocaleEN, Radix: true})
+	is.NoErr(err)
+	is.Equal(int64(31), i.Int64) // Value must match
+	err = ft.Unmarshal([]byte(`"1.234.567"`), &i, ft.Policy{Locale: ft.LocaleDE})
+	is.NoErr(err)
+	is.Equal(int64(1234567), i.Int64) // Value must match
+	p := ft.Policy{Locale: ft.LocaleDE, FloatToInt: ft.FloatToIntReject}
+	err = ft.Unmarshal([]byte(`"2,5"`), &i, p)
+	is.Equal("value 2.5 has a fractional part", err.Error())
+
+	// Per field
+	type Data struct {
+		Price ft.Float `json:"price" ft:"locale=de"`
+		Qty   ft.Int   `json:"qty" ft:"locale=en"`
+	}
+	d := Data{}
+	err = ft.Unmarshal([]byte(`{"price": "1.299,00", "qty": "1,000"}`), &d,
+		ft.Policy{})
+	is.NoErr(err)
+	is.Equal(1299.0, d.Price.Float64)  // Value must match
+	is.Equal(int64(1000), d.Qty.Int64) // Value must match
+}
@@ -0,0 +1,99 @@
+package ft
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalise is a bit set of normalisation steps that ft.String and
+// ft.NString apply to strings when decoding. Steps are applied in the
+// order they are listed, after Policy.Trim, and before MaxRunes
+type Normalise uint8
+
+const (
+	// NormaliseStrip removes control characters, except white space
+	// like tab and newline, and zero-width characters like "\u200b"
+	NormaliseStrip Normalise = 1 << iota
+	// NormaliseNFC applies Unicode canonical composition,
+	// e.g. "e\u0301" is "\u00e9"
+	NormaliseNFC
+	// NormaliseNFKC applies Unicode compatibility composition,
+	// e.g. "ﬁ" is "fi" and full-width "Ａ" is "A"
+	NormaliseNFKC
+	// NormaliseCollapse replaces runs of white space with a single space,
+	// and removes leading and trailing white space
+	NormaliseCollapse
+	// NormaliseFold applies Unicode case folding,
+	// e.g. "Straße" is "strasse"
+	NormaliseFold
+)
+
+var normaliseNames = []struct {
+	step Normalise
+	name string
+}{
+	{NormaliseStrip, "strip"},
+	{NormaliseNFC, "nfc"},
+	{NormaliseNFKC, "nfkc"},
+	{NormaliseCollapse, "collapse"},
+	{NormaliseFold, "fold"},
+}
+
+func (n Normalise) String() string {
+	names := []string{}
+	for _, nn := range normaliseNames {
+		if n&nn.step != 0 {
+			names = append(names, nn.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// zeroWidth are removed by NormaliseStrip
+var zeroWidth = []rune{
+	'\u200b', // Zero width space
+	'\u200c', // Zero width non-joiner
+	'\u200d', // Zero width joiner
+	'\u2060', // Word joiner
+	'\ufeff', // Zero width no-break space, byte order mark
+}
+
+// normalise applies the Normalise and MaxRunes policy to s
+func (p *Policy) normalise(s string) string {
+	if p.Normalise&NormaliseStrip != 0 {
+		s = strings.Map(func(r rune) rune {
+			if (unicode.IsControl(r) && !unicode.IsSpace(r)) ||
+				containsRune(zeroWidth, r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	if p.Normalise&NormaliseNFC != 0 {
+		s = norm.NFC.String(s)
+	}
+	if p.Normalise&NormaliseNFKC != 0 {
+		s = norm.NFKC.String(s)
+	}
+	if p.Normalise&NormaliseCollapse != 0 {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	if p.Normalise&NormaliseFold != 0 {
+		s = cases.Fold().String(s)
+	}
+	if p.MaxRunes > 0 && utf8.RuneCountInString(s) > p.MaxRunes {
+		i, n := 0, 0
+		for i = range s {
+			if n == p.MaxRunes {
+				break
+			}
+			n++
+		}
+		s = s[:i]
+	}
+	return s
+}
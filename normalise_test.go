@@ -0,0 +1,76 @@
+package ft_test
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestNormalise(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		policy ft.Policy
+		input  string
+		want   string
+	}{
+		// Raw by default
+		{ft.Policy{}, `" a  b\u200b\u0007 "`, " a  b\u200b\u0007 "},
+		{ft.Policy{Normalise: ft.NormaliseStrip},
+			`"a\u200bb\u0007c\td\u00ade\ufeff"`, "abc\td\u00ade"},
+		{ft.Policy{Normalise: ft.NormaliseNFC},
+			`"Cafe\u0301"`, "Caf\u00e9"},
+		{ft.Policy{Normalise: ft.NormaliseNFKC},
+			`"\ufb01ne \uff21"`, "fine A"},
+		{ft.Policy{Normalise: ft.NormaliseCollapse},
+			`"  a \t\n b  c "`, "a b c"},
+		{ft.Policy{Normalise: ft.NormaliseFold},
+			`"Stra\u00dfe"`, "strasse"},
+		{ft.Policy{MaxRunes: 3}, `"h\u00e9llo"`, "h\u00e9l"},
+		{ft.Policy{MaxRunes: 10}, `"hello"`, "hello"},
+		{ft.Policy{
+			Normalise: ft.NormaliseStrip | ft.NormaliseNFC |
+				ft.NormaliseCollapse | ft.NormaliseFold,
+			MaxRunes: 9,
+		}, `" JOSE\u0301 \u200b  DE  LA\u0000 CRUZ "`, "jos\u00e9 de l"},
+	}
+	for _, tt := range tests {
+		s := ft.String{}
+		err := ft.Unmarshal([]byte(tt.input), &s, tt.policy)
+		is.NoErr(err)
+		is.Equal(tt.want, s.String) // Value must match
+
+		ns := ft.NString{}
+		err = ft.Unmarshal([]byte(tt.input), &ns, tt.policy)
+		is.NoErr(err)
+		is.Equal(tt.want, ns.String) // Value must match
+	}
+
+	// Empty after normalisation
+	ns := ft.NString{}
+	p := ft.Policy{Normalise: ft.NormaliseStrip, EmptyString: ft.EmptyStringNull}
+	err := ft.Unmarshal([]byte(`"\u200b"`), &ns, p)
+	is.NoErr(err)
+	is.Equal(false, ns.Valid) // Must not be valid
+
+	// Per field
+	type Data struct {
+		Name ft.String `json:"name" ft:"normalise=strip|collapse,maxrunes=5"`
+		Code ft.String `json:"code" ft:"normalise=nfkc|fold"`
+	}
+	d := Data{}
+	err = ft.Unmarshal([]byte(`{"name": " Jane \u200b Doe", "code": "\uff21BC"}`),
+		&d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal("Jane ", d.Name.String) // Value must match
+	is.Equal("abc", d.Code.String)   // Value must match
+
+	// Invalid tags
+	type Invalid struct {
+		Name ft.String `ft:"normalise=upper"`
+	}
+	err = ft.Unmarshal([]byte(`{}`), &Invalid{}, ft.Policy{})
+	is.Equal(`ft: invalid tag on field ft_test.Invalid.Name: `+
+		`unknown normalise step "upper"`, err.Error())
+}
@@ -0,0 +1,188 @@
+package ft
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Numeric strings are decoded by ft.Int, ft.NInt, ft.Float and ft.NFloat
+// with the same grammar as bare JSON numbers, so "12.0" and 12.0 decode
+// to the same value. Leading and trailing JSON white space is ignored.
+//
+//	number   = [ "-" ] int [ frac ] [ exp ]
+//	int      = "0" | digit1-9 *digit
+//	frac     = "." 1*digit
+//	exp      = ( "e" | "E" ) [ "-" | "+" ] 1*digit
+//
+// So "+1", "01", ".5", "5." and "0x1F" are not numbers by default.
+// The policy extends the grammar:
+//
+//   - Locale accepts numbers formatted for the locale, e.g. "1.234,56",
+//     if they are not decoded with the JSON grammar first
+//   - Radix accepts a plus sign, leading zeros, base prefixes and
+//     underscores for integers, e.g. "+1", "01", "0x1F" and "1_000"
+//   - NonFinite decides how "NaN", "Inf" and "Infinity" are decoded
+//     by ft.Float and ft.NFloat, they are accepted by default
+//   - NumericStrings restricts integer strings, decodes the longest
+//     numeric prefix of strings, e.g. "12abc" is 12, or empty strings
+//     as zero
+//
+// Empty and white space only strings are not numbers, ft.NInt and
+// ft.NFloat decode them as null, and ft.Int and ft.Float return an error.
+// NumericStringsEmptyZero decodes them as zero for all four types,
+// see Policy.EmptyString to change the rule for all types
+
+// jsonSpace is JSON insignificant white space
+const jsonSpace = " \t\n\r"
+
+// emptyNull returns true if s is empty or white space only,
+// and decodes as null for a nullable numeric type
+func (p *Policy) emptyNull(s string, nullable bool) bool {
+	return nullable && p.NumericStrings != NumericStringsEmptyZero &&
+		strings.TrimSpace(s) == ""
+}
+
+// numeric returns the numeric string s in the form parsed by strconv.
+// Strings that do not match the grammar return a strconv.NumError
+// for the function fn, i.e. ParseInt or ParseFloat,
+// as per the NumericStrings policy
+func (p *Policy) numeric(s string, fn string) (string, error) {
+	n, err := p.number(strings.Trim(s, jsonSpace), fn)
+	switch {
+	case err != nil && p.NumericStrings == NumericStringsPrefix:
+		return numberPrefix(n), nil
+	case err != nil && p.NumericStrings == NumericStringsEmptyZero && n == "":
+		return "0", nil
+	case err == nil && fn == "ParseInt" &&
+		p.NumericStrings == NumericStringsInteger &&
+		!isRadix(n) && strings.ContainsAny(n, ".eE"):
+		return s, &strconv.NumError{Func: fn, Num: n, Err: strconv.ErrSyntax}
+	}
+	return n, err
+}
+
+// number returns the trimmed numeric string n in the form parsed by
+// strconv, n is returned with the error if it does not match the grammar.
+// The locale is a fallback for strings that are not JSON numbers, radix
+// integers or non-finite, except that strings with a "." use the locale
+// first if its decimal separator is not ".", e.g. "1.234" with LocaleDE
+func (p *Policy) number(n string, fn string) (string, error) {
+	if p.Locale != nil && p.Locale.Decimal != '.' && strings.Contains(n, ".") {
+		return p.localeNumber(n)
+	}
+	if isNumber(n) {
+		return n, nil
+	}
+	if fn == "ParseInt" && p.Radix {
+		// Radix also accepts a plus sign and leading zeros
+		digits := strings.TrimPrefix(n, "+")
+		if digits != n && strings.HasPrefix(digits, "-") {
+			return n, &strconv.NumError{Func: fn, Num: n, Err: strconv.ErrSyntax}
+		}
+		if isNumber(digits) || isRadix(digits) ||
+			isDigits(strings.TrimPrefix(digits, "-")) {
+			return digits, nil
+		}
+	}
+	if fn == "ParseFloat" && isNonFinite(n) {
+		return n, nil
+	}
+	if p.Locale != nil {
+		return p.localeNumber(n)
+	}
+	return n, &strconv.NumError{Func: fn, Num: n, Err: strconv.ErrSyntax}
+}
+
+// localeNumber normalises n as per the Locale policy
+func (p *Policy) localeNumber(n string) (string, error) {
+	l, err := p.Locale.normalise(n)
+	if err != nil {
+		return n, err
+	}
+	return l, nil
+}
+
+// numberPrefix returns the longest prefix of s that is a decimal number,
+// in the form parsed by strconv, or "0" if there is none.
+// A sign, leading zeros and a decimal point without digits on one side
+// are accepted, e.g. "+.5", "012" and "5."
+func numberPrefix(s string) string {
+	i := 0
+	digits := func() int {
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i - start
+	}
+
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		i++
+	}
+	n := digits()
+	if i < len(s) && s[i] == '.' {
+		i++
+		n += digits()
+	}
+	if n == 0 {
+		return "0"
+	}
+	end := i
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '-' || s[i] == '+') {
+			i++
+		}
+		if digits() > 0 {
+			end = i
+		}
+	}
+	return s[:end]
+}
+
+// isNumber returns true if s matches the JSON number grammar
+func isNumber(s string) bool {
+	i := 0
+	digits := func() int {
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i - start
+	}
+
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	if i < len(s) && s[i] == '0' {
+		i++
+	} else if digits() == 0 {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if digits() == 0 {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '-' || s[i] == '+') {
+			i++
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+// isNonFinite returns true if s is NaN or infinity as accepted by
+// strconv.ParseFloat, e.g. "NaN", "-Inf" or "+Infinity"
+func isNonFinite(s string) bool {
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		s = s[1:]
+	}
+	s = strings.ToLower(s)
+	return s == "nan" || s == "inf" || s == "infinity"
+}
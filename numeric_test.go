@@ -0,0 +1,152 @@
+package ft_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+// numericTypes are the columns in the numeric conformance table
+var numericTypes = []struct {
+	name string
+	new  func() interface{}
+}{
+	{"Int", func() interface{} { return &ft.Int{} }},
+	{"NInt", func() interface{} { return &ft.NInt{} }},
+	{"Float", func() interface{} { return &ft.Float{} }},
+	{"NFloat", func() interface{} { return &ft.NFloat{} }},
+}
+
+// decodeResult returns the value decoded from the input as JSON,
+// or "error"
+func decodeResult(input string, v interface{}, p ft.Policy) string {
+	if err := ft.Unmarshal([]byte(input), v, p); err != nil {
+		return "error"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "error"
+	}
+	return string(b)
+}
+
+func TestNumericGrammar(t *testing.T) {
+	is := is.New(t)
+
+	// Conformance table for numeric strings with the default policy,
+	// the columns are Int, NInt, Float and NFloat
+	tests := []struct {
+		input string
+		want  [4]string
+	}{
+		{`"0"`, [4]string{"0", "0", "0", "0"}},
+		{`"-0"`, [4]string{"0", "0", "-0", "-0"}},
+		{`"12"`, [4]string{"12", "12", "12", "12"}},
+		{`"-12"`, [4]string{"-12", "-12", "-12", "-12"}},
+		{`"12.0"`, [4]string{"12", "12", "12", "12"}},
+		{`"12.5"`, [4]string{"12", "12", "12.5", "12.5"}},
+		{`"1e3"`, [4]string{"1000", "1000", "1000", "1000"}},
+		{`"1E+3"`, [4]string{"1000", "1000", "1000", "1000"}},
+		{`"25e-1"`, [4]string{"2", "2", "2.5", "2.5"}},
+		{`" 12 "`, [4]string{"12", "12", "12", "12"}},
+		{`"\t12\n"`, [4]string{"12", "12", "12", "12"}},
+		{`"+12"`, [4]string{"error", "error", "error", "error"}},
+		{`"012"`, [4]string{"error", "error", "error", "error"}},
+		{`".5"`, [4]string{"error", "error", "error", "error"}},
+		{`"5."`, [4]string{"error", "error", "error", "error"}},
+		{`"1e"`, [4]string{"error", "error", "error", "error"}},
+		{`"--1"`, [4]string{"error", "error", "error", "error"}},
+		{`"1 2"`, [4]string{"error", "error", "error", "error"}},
+		{`"0x1F"`, [4]string{"error", "error", "error", "error"}},
+		{`"0x1p3"`, [4]string{"error", "error", "error", "error"}},
+		{`"1_000"`, [4]string{"error", "error", "error", "error"}},
+		{"\"\u00a012\"", [4]string{"error", "error", "error", "error"}},
+		{`"abc"`, [4]string{"error", "error", "error", "error"}},
+		{`"NaN"`, [4]string{"error", "error", `"NaN"`, `"NaN"`}},
+		{`"-Infinity"`, [4]string{"error", "error", `"-Infinity"`, `"-Infinity"`}},
+		{`""`, [4]string{"error", "null", "error", "null"}},
+		{`" "`, [4]string{"error", "null", "error", "null"}},
+	}
+	for _, tt := range tests {
+		for i, nt := range numericTypes {
+			got := decodeResult(tt.input, nt.new(), ft.Policy{})
+			if got != tt.want[i] {
+				t.Errorf("%s %s: %s != %s", nt.name, tt.input, got, tt.want[i])
+			}
+		}
+	}
+
+	// Numbers in strings must decode like bare JSON numbers
+	numbers := []string{
+		`0`, `-0`, `1`, `-1`, `12.0`, `12.5`, `-12.5`, `0.001`, `1e3`, `1E3`,
+		`1e+3`, `25e-1`, `9007199254740993`, `9223372036854775807`,
+		`9223372036854775808`, `1e400`, `1e-400`,
+	}
+	for _, n := range numbers {
+		for _, nt := range numericTypes {
+			want := decodeResult(n, nt.new(), ft.Policy{})
+			got := decodeResult(`"`+n+`"`, nt.new(), ft.Policy{})
+			is.Equal(want, got) // String must decode like number
+			got = decodeResult(`" `+n+` "`, nt.new(), ft.Policy{})
+			is.Equal(want, got) // White space must be ignored
+		}
+	}
+
+	// Errors match strconv
+	err := ft.Unmarshal([]byte(`"+12"`), &ft.Int{}, ft.Policy{})
+	is.Equal(`strconv.ParseInt: parsing "+12": invalid syntax`, err.Error())
+	err = ft.Unmarshal([]byte(`" 1_0 "`), &ft.Float{}, ft.Policy{})
+	is.Equal(`strconv.ParseFloat: parsing "1_0": invalid syntax`, err.Error())
+}
+
+func TestNumericStrings(t *testing.T) {
+	is := is.New(t)
+
+	// The columns are Int, NInt, Float and NFloat
+	tests := []struct {
+		mode  ft.NumericStrings
+		input string
+		want  [4]string
+	}{
+		{ft.NumericStringsInteger, `"12"`, [4]string{"12", "12", "12", "12"}},
+		{ft.NumericStringsInteger, `"12.5"`, [4]string{"error", "error", "12.5", "12.5"}},
+		{ft.NumericStringsInteger, `"12.0"`, [4]string{"error", "error", "12", "12"}},
+		{ft.NumericStringsInteger, `"1e3"`, [4]string{"error", "error", "1000", "1000"}},
+		{ft.NumericStringsInteger, `12.5`, [4]string{"12", "12", "12.5", "12.5"}},
+		{ft.NumericStringsPrefix, `"12abc"`, [4]string{"12", "12", "12", "12"}},
+		{ft.NumericStringsPrefix, `" -1.5e3kg"`, [4]string{"-1500", "-1500", "-1500", "-1500"}},
+		{ft.NumericStringsPrefix, `"+.5"`, [4]string{"0", "0", "0.5", "0.5"}},
+		{ft.NumericStringsPrefix, `"012"`, [4]string{"12", "12", "12", "12"}},
+		{ft.NumericStringsPrefix, `"5e"`, [4]string{"5", "5", "5", "5"}},
+		{ft.NumericStringsPrefix, `"abc"`, [4]string{"0", "0", "0", "0"}},
+		{ft.NumericStringsPrefix, `"-"`, [4]string{"0", "0", "0", "0"}},
+		{ft.NumericStringsPrefix, `""`, [4]string{"0", "null", "0", "null"}},
+		{ft.NumericStringsEmptyZero, `""`, [4]string{"0", "0", "0", "0"}},
+		{ft.NumericStringsEmptyZero, `" "`, [4]string{"0", "0", "0", "0"}},
+		{ft.NumericStringsEmptyZero, `"abc"`, [4]string{"error", "error", "error", "error"}},
+	}
+	for _, tt := range tests {
+		for i, nt := range numericTypes {
+			got := decodeResult(tt.input, nt.new(),
+				ft.Policy{NumericStrings: tt.mode})
+			if got != tt.want[i] {
+				t.Errorf("%d %s %s: %s != %s",
+					tt.mode, nt.name, tt.input, got, tt.want[i])
+			}
+		}
+	}
+
+	// Struct tag
+	type Data struct {
+		Qty ft.Int `json:"qty" ft:"numeric=prefix"`
+	}
+	d := Data{}
+	err := ft.Unmarshal([]byte(`{"qty": "3 pcs"}`), &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(int64(3), d.Qty.Int64) // Value must match
+	err = ft.Unmarshal([]byte(`"2.5"`), &ft.Int{},
+		ft.Policy{NumericStrings: ft.NumericStringsInteger})
+	is.Equal(`strconv.ParseInt: parsing "2.5": invalid syntax`, err.Error())
+}
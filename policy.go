@@ -0,0 +1,446 @@
+package ft
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kind is a bit set of JSON value kinds
+type Kind uint8
+
+const (
+	KindNull Kind = 1 << iota
+	KindString
+	KindNumber
+	KindBool
+	KindObject
+	KindArray
+)
+
+// KindScalar is any JSON value that is not an object or array
+const KindScalar = KindNull | KindString | KindNumber | KindBool
+
+var kindNames = []struct {
+	kind Kind
+	name string
+}{
+	{KindNull, "null"},
+	{KindString, "string"},
+	{KindNumber, "number"},
+	{KindBool, "bool"},
+	{KindObject, "object"},
+	{KindArray, "array"},
+}
+
+func (k Kind) String() string {
+	names := []string{}
+	for _, kn := range kindNames {
+		if k&kn.kind != 0 {
+			names = append(names, kn.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// kindOf returns the Kind of the JSON value
+func kindOf(bArr []byte) Kind {
+	for _, c := range bArr {
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case 'n':
+			return KindNull
+		case '"':
+			return KindString
+		case 't', 'f':
+			return KindBool
+		case '{':
+			return KindObject
+		case '[':
+			return KindArray
+		}
+		return KindNumber
+	}
+	return 0
+}
+
+// kindError is returned for values of a kind that is not accepted
+func kindError(k Kind) error {
+	switch k {
+	case KindNull:
+		return errors.Errorf("value is null")
+	case KindObject, KindArray:
+		return errors.Errorf("value is an %s", k)
+	}
+	return errors.Errorf("value is a %s", k)
+}
+
+// FloatToInt controls how numbers with a fractional part
+// are converted to integers
+type FloatToInt uint8
+
+const (
+	// FloatToIntTruncate discards the fractional part, this is the default
+	FloatToIntTruncate FloatToInt = iota
+	// FloatToIntReject returns an error if there is a fractional part,
+	// integral numbers like 3.0 and 1e3 are accepted
+	FloatToIntReject
+	// FloatToIntStrict returns an error for numbers with a decimal point
+	// or exponent, also if they are integral
+	FloatToIntStrict
+	// FloatToIntFloor rounds towards negative infinity
+	FloatToIntFloor
+	// FloatToIntCeil rounds towards positive infinity
+	FloatToIntCeil
+	// FloatToIntHalfUp rounds to the nearest integer,
+	// halves are rounded away from zero
+	FloatToIntHalfUp
+	// FloatToIntHalfEven rounds to the nearest integer,
+	// halves are rounded to the nearest even integer
+	FloatToIntHalfEven
+)
+
+// floatToIntNames are used by the round struct tag directive
+var floatToIntNames = map[string]FloatToInt{
+	"truncate":  FloatToIntTruncate,
+	"reject":    FloatToIntReject,
+	"strict":    FloatToIntStrict,
+	"floor":     FloatToIntFloor,
+	"ceil":      FloatToIntCeil,
+	"half_up":   FloatToIntHalfUp,
+	"half_even": FloatToIntHalfEven,
+}
+
+// Default kinds accepted by each type, used if the Policy field is zero
+const (
+	defaultStringKinds = KindScalar
+	defaultIntKinds    = KindNull | KindString | KindNumber
+	defaultFloatKinds  = KindNull | KindString | KindNumber
+	defaultBoolKinds   = KindScalar
+)
+
+// Policy controls how JSON values are coerced to ft types.
+// The zero value is the default policy, it matches the behaviour of the
+// UnmarshalJSON methods. Use a Decoder to un-marshal with a policy,
+// this does not modify global state, so concurrent decodes may use
+// different policies
+type Policy struct {
+	// StringKinds are the JSON kinds accepted by ft.String and ft.NString.
+	// Zero means any scalar value, and objects and arrays unless
+	// StringComposite is StringCompositeError.
+	// KindNull only applies to types without the N-prefix,
+	// null is always accepted by N-types
+	StringKinds Kind
+	// StringComposite controls how ft.String and ft.NString coerce
+	// objects and arrays
+	StringComposite StringComposite
+	// JoinSeparator is used by StringCompositeJoin, zero means ","
+	JoinSeparator string
+	// Normalise are the normalisation steps ft.String and ft.NString
+	// apply to strings, e.g. NormaliseStrip | NormaliseCollapse
+	Normalise Normalise
+	// MaxRunes truncates strings decoded by ft.String and ft.NString
+	// to the maximum number of runes, zero means no maximum
+	MaxRunes int
+	// IntKinds are the JSON kinds accepted by ft.Int and ft.NInt.
+	// Zero means null, strings and numbers
+	IntKinds Kind
+	// FloatKinds are the JSON kinds accepted by ft.Float and ft.NFloat.
+	// Zero means null, strings and numbers
+	FloatKinds Kind
+	// BoolKinds are the JSON kinds accepted by ft.Bool and ft.NBool.
+	// Zero means any scalar value
+	BoolKinds Kind
+	// FloatToInt controls how ft.Int and ft.NInt convert numbers with a
+	// fractional part, this includes numeric strings like "2.5"
+	FloatToInt FloatToInt
+	// Radix accepts integer strings with the base prefixes 0x, 0o and
+	// 0b, and underscores between digits, e.g. "0x1F" and "1_000_000".
+	// A plus sign and leading zeros are also accepted, e.g. "+017"
+	Radix bool
+	// NumericStrings controls how ft.Int, ft.NInt, ft.Float and ft.NFloat
+	// decode strings that are not JSON numbers, e.g. "2.5" or "12abc"
+	NumericStrings NumericStrings
+	// Overflow controls how numbers that are out of range for the
+	// type are decoded
+	Overflow Overflow
+	// AllowPrecisionLoss rounds integers that ft.Float can not represent
+	// exactly, e.g. 2^53+1 or 9007199254740993.0, and underflows non-zero
+	// numbers like 1e-400 to zero, instead of returning an error.
+	// ft.Int always converts numbers exactly
+	AllowPrecisionLoss bool
+	// NonFinite controls how ft.Float and ft.NFloat decode the strings
+	// "NaN", "Inf", "Infinity" and their signed forms
+	NonFinite NonFinite
+	// Locale is used by the numeric types to parse strings formatted
+	// for the locale, e.g. "1.234,56" with LocaleDE.
+	// Nil means numeric strings must be formatted like JSON numbers
+	Locale *Locale
+	// Unit is the canonical unit of ft.Quantity, e.g. "kg". Values are
+	// converted to it, and bare numbers are assumed to be in it.
+	// Empty means the decoded unit is kept, see the unit struct tag
+	// directive
+	Unit string
+	// UnwrapArrays decodes a single element array, e.g. ["3"],
+	// like the element. Other arrays will error.
+	// StringComposite takes precedence for ft.String and ft.NString,
+	// arrays are not unwrapped unless it is StringCompositeError
+	UnwrapArrays bool
+	// Trim removes leading and trailing white space from JSON strings
+	// before they are coerced
+	Trim bool
+	// RejectNull returns an error for null values, also for N-types
+	RejectNull bool
+	// DuplicateKeys controls how objects with duplicate keys are decoded,
+	// it applies to the whole document
+	DuplicateKeys DuplicateKeys
+	// KeyMatch controls how object keys are matched to struct fields
+	// and their aliases, see the alias struct tag directive
+	KeyMatch KeyMatch
+	// NullStrings are strings that N-types decode as null,
+	// e.g. "None", "N/A" or "-". Strings are compared case-insensitively,
+	// ignoring leading and trailing white space.
+	// Nil means no strings are null
+	NullStrings []string
+	// EmptyString controls how empty and white space only strings are
+	// decoded, by default each type keeps its own rule
+	EmptyString EmptyString
+	// EmptyText controls how empty text is decoded by the UnmarshalText
+	// methods. By default N-types are null, and other types are decoded
+	// as per EmptyString
+	EmptyText EmptyString
+	// BoolStrings controls how ft.Bool and ft.NBool coerce strings
+	BoolStrings BoolStrings
+	// TrueStrings are the strings ft.Bool and ft.NBool coerce to true.
+	// Strings are compared case-insensitively,
+	// ignoring leading and trailing white space.
+	// Nil means "true", "1", "yes", "y", "on", "t" and "enabled"
+	TrueStrings []string
+	// FalseStrings are the strings ft.Bool and ft.NBool coerce to false.
+	// Nil means "false", "0", "no", "n", "off", "f", "disabled" and "".
+	// With BoolStringsPermissive nil means "false", "0" and ""
+	FalseStrings []string
+}
+
+// Overflow controls how numbers that are out of range are decoded
+type Overflow uint8
+
+const (
+	// OverflowError returns a NumberError, this is the default
+	OverflowError Overflow = iota
+	// OverflowSaturate clamps the value to the bounds of the type
+	OverflowSaturate
+)
+
+// overflowNames are used by the overflow struct tag directive
+var overflowNames = map[string]Overflow{
+	"error":    OverflowError,
+	"saturate": OverflowSaturate,
+}
+
+// NumericStrings controls how numeric types decode strings
+type NumericStrings uint8
+
+const (
+	// NumericStringsJSON accepts strings with the JSON number grammar,
+	// as extended by the Locale and Radix policy, this is the default
+	NumericStringsJSON NumericStrings = iota
+	// NumericStringsInteger is like NumericStringsJSON, except that
+	// ft.Int and ft.NInt only accept integer strings, e.g. "2.5" and
+	// "1e3" will error like Python int(). Numbers are not affected
+	NumericStringsInteger
+	// NumericStringsPrefix decodes the longest prefix of the string
+	// that is a number, strings without one are zero, like PHP casts.
+	// E.g. "12abc" is 12, "abc" is 0 and "1.5e3kg" is 1500
+	NumericStringsPrefix
+	// NumericStringsEmptyZero is like NumericStringsJSON, except that
+	// empty and white space only strings are zero, like JavaScript
+	// Number(""). N-types are valid, instead of null. Strings are not
+	// affected, see EmptyString to change the rule for all types
+	NumericStringsEmptyZero
+)
+
+// numericStringsNames are used by the numeric struct tag directive
+var numericStringsNames = map[string]NumericStrings{
+	"json":      NumericStringsJSON,
+	"integer":   NumericStringsInteger,
+	"prefix":    NumericStringsPrefix,
+	"emptyzero": NumericStringsEmptyZero,
+}
+
+// NonFinite controls how strings for NaN and infinity are decoded.
+// Regardless of the policy, MarshalJSON encodes non-finite values as
+// the JSON strings "NaN", "Infinity" and "-Infinity"
+type NonFinite uint8
+
+const (
+	// NonFiniteString decodes the strings to non-finite values,
+	// this is the default
+	NonFiniteString NonFinite = iota
+	// NonFiniteReject returns an error
+	NonFiniteReject
+	// NonFiniteNull decodes the strings as if the value is null,
+	// i.e. ft.NFloat is not valid and ft.Float is zero
+	NonFiniteNull
+)
+
+// nonFiniteNames are used by the nonfinite struct tag directive
+var nonFiniteNames = map[string]NonFinite{
+	"string": NonFiniteString,
+	"reject": NonFiniteReject,
+	"null":   NonFiniteNull,
+}
+
+// EmptyString controls how empty and white space only strings are decoded
+type EmptyString uint8
+
+const (
+	// EmptyStringKeep keeps the rule of each type, this is the default.
+	// ft.String and ft.NString keep the string, ft.Bool and ft.NBool are
+	// false, ft.NInt and ft.NFloat are null, and ft.Int and ft.Float
+	// will error, see NumericStrings
+	EmptyStringKeep EmptyString = iota
+	// EmptyStringNull decodes the string as if the value is null
+	EmptyStringNull
+	// EmptyStringZero decodes the string to the zero value of the type,
+	// N-types are valid
+	EmptyStringZero
+	// EmptyStringError returns an error
+	EmptyStringError
+)
+
+// KeyMatch controls how object keys are matched to struct fields.
+// An exact match of the field name or an alias is always preferred
+type KeyMatch uint8
+
+const (
+	// KeyMatchFold matches keys case-insensitively like encoding/json,
+	// this is the default. The first matching field is used
+	KeyMatchFold KeyMatch = iota
+	// KeyMatchExact only matches keys that are equal to the name
+	KeyMatchExact
+	// KeyMatchStyle matches keys case-insensitively, ignoring the
+	// separators "_", "-", "." and space, so "sku_id", "skuId", "SkuID"
+	// and "sku-id" are the same key.
+	// Keys that match more than one field will error
+	KeyMatchStyle
+)
+
+// keyMatchNames are used by the keys struct tag directive
+var keyMatchNames = map[string]KeyMatch{
+	"fold":  KeyMatchFold,
+	"exact": KeyMatchExact,
+	"style": KeyMatchStyle,
+}
+
+// emptyStringNames are used by the empty struct tag directive
+var emptyStringNames = map[string]EmptyString{
+	"keep":  EmptyStringKeep,
+	"null":  EmptyStringNull,
+	"zero":  EmptyStringZero,
+	"error": EmptyStringError,
+}
+
+// StringComposite controls how objects and arrays are coerced to string
+type StringComposite uint8
+
+const (
+	// StringCompositeError returns an error, this is the default
+	StringCompositeError StringComposite = iota
+	// StringCompositeRaw uses the compact JSON, e.g. {"a":1}
+	StringCompositeRaw
+	// StringCompositeJoin joins the array elements coerced to string,
+	// e.g. ["a",1] is "a,1", see JoinSeparator. Objects will error
+	StringCompositeJoin
+)
+
+// stringCompositeNames are used by the composite struct tag directive
+var stringCompositeNames = map[string]StringComposite{
+	"error": StringCompositeError,
+	"raw":   StringCompositeRaw,
+	"join":  StringCompositeJoin,
+}
+
+// BoolStrings controls how strings are coerced to bool
+type BoolStrings uint8
+
+const (
+	// BoolStringsStrict returns an error for strings that are not in
+	// TrueStrings or FalseStrings, this is the default
+	BoolStringsStrict BoolStrings = iota
+	// BoolStringsPermissive coerces strings in FalseStrings to false,
+	// all other strings are true
+	BoolStringsPermissive
+)
+
+// boolStringsNames are used by the bools struct tag directive
+var boolStringsNames = map[string]BoolStrings{
+	"strict":     BoolStringsStrict,
+	"permissive": BoolStringsPermissive,
+}
+
+var (
+	defaultTrueStrings = []string{
+		"true", "1", "yes", "y", "on", "t", "enabled"}
+	defaultFalseStrings = []string{
+		"false", "0", "no", "n", "off", "f", "disabled", ""}
+	permissiveFalseStrings = []string{"false", "0", ""}
+)
+
+// defaultPolicy is used by the UnmarshalJSON methods
+var defaultPolicy = Policy{}
+
+// accept returns an error if kinds does not include k.
+// Null is accepted by nullable types, unless RejectNull is set
+func (p *Policy) accept(k, kinds, defaultKinds Kind, nullable bool) error {
+	if kinds == 0 {
+		kinds = defaultKinds
+	}
+	if k == KindNull {
+		if p.RejectNull {
+			return kindError(k)
+		}
+		if nullable {
+			return nil
+		}
+	}
+	if kinds&k == 0 {
+		return kindError(k)
+	}
+	return nil
+}
+
+// parseBool coerces the string to bool as per the BoolStrings policy
+func (p *Policy) parseBool(s string) (bool, error) {
+	trueStrings, falseStrings := p.TrueStrings, p.FalseStrings
+	if trueStrings == nil {
+		trueStrings = defaultTrueStrings
+	}
+	if falseStrings == nil {
+		falseStrings = defaultFalseStrings
+		if p.BoolStrings == BoolStringsPermissive {
+			falseStrings = permissiveFalseStrings
+		}
+	}
+
+	if containsFold(falseStrings, s) {
+		return false, nil
+	}
+	if p.BoolStrings == BoolStringsPermissive || containsFold(trueStrings, s) {
+		return true, nil
+	}
+	return false, errors.Errorf("value %q is not a recognised bool", s)
+}
+
+// containsFold returns true if list contains s, compared
+// case-insensitively, ignoring leading and trailing white space
+func containsFold(list []string, s string) bool {
+	s = strings.TrimSpace(s)
+	for _, item := range list {
+		if strings.EqualFold(s, strings.TrimSpace(item)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package ft
+
+// Profiles are policies that approximate the loose typing of the
+// ecosystems API clients are commonly written in. Select a profile per
+// decode, e.g. ft.Unmarshal(b, &v, ft.ProfileJS()).
+// Each call returns a new policy, so it may be changed by the caller.
+// The full truth table for each profile is in testdata/profiles.golden
+
+// ProfileStrict only accepts the natural JSON kind of each type.
+// Null is an error for types without the N-prefix,
+// and numbers with a fractional part are not converted to integers
+func ProfileStrict() Policy {
+	return Policy{
+		StringKinds: KindString,
+		IntKinds:    KindNumber,
+		FloatKinds:  KindNumber,
+		BoolKinds:   KindBool,
+		FloatToInt:  FloatToIntReject,
+	}
+}
+
+// ProfileJS follows JavaScript type conversion, e.g. Number(true) is 1,
+// Number("") is 0, and String([1, 2]) is "1,2".
+// Only the empty string is falsy, so "0" and "false" are true.
+// N-types decode the strings "null" and "undefined" as null.
+// Unlike Number("0x1F"), strings with a base prefix are not numbers,
+// because Radix also accepts underscores and signed prefixes that
+// JavaScript rejects, e.g. "1_000" and "-0x1F". Set Radix to accept them
+func ProfileJS() Policy {
+	return Policy{
+		IntKinds:        KindScalar,
+		FloatKinds:      KindScalar,
+		NumericStrings:  NumericStringsEmptyZero,
+		StringComposite: StringCompositeJoin,
+		BoolStrings:     BoolStringsPermissive,
+		FalseStrings:    []string{""},
+		NullStrings:     []string{"null", "undefined"},
+	}
+}
+
+// ProfilePHP follows PHP type juggling, e.g. (int)true is 1,
+// (int)"12abc" is 12, and (int)"abc" and (int)"" are 0.
+// The strings "" and "0" are false
+func ProfilePHP() Policy {
+	return Policy{
+		IntKinds:       KindScalar,
+		FloatKinds:     KindScalar,
+		NumericStrings: NumericStringsPrefix,
+		BoolStrings:    BoolStringsPermissive,
+		FalseStrings:   []string{"", "0"},
+	}
+}
+
+// ProfilePython follows Python type conversion, e.g. int(True) is 1,
+// int("2.5") is an error, and float("inf") is infinity.
+// Only the empty string is falsy.
+// N-types decode the string "None" as null
+func ProfilePython() Policy {
+	return Policy{
+		IntKinds:       KindScalar,
+		FloatKinds:     KindScalar,
+		NumericStrings: NumericStringsInteger,
+		BoolStrings:    BoolStringsPermissive,
+		FalseStrings:   []string{""},
+		NullStrings:    []string{"None"},
+	}
+}
@@ -0,0 +1,212 @@
+package ft_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// profileInputs are the JSON values in the profile truth table
+var profileInputs = []string{
+	`null`,
+	`""`,
+	`" "`,
+	`"0"`,
+	`"1"`,
+	`"false"`,
+	`"true"`,
+	`"null"`,
+	`"undefined"`,
+	`"None"`,
+	`"1e3"`,
+	`"2.5"`,
+	`"abc"`,
+	`"12abc"`,
+	`"0x1F"`,
+	`0`,
+	`1`,
+	`2.5`,
+	`1e3`,
+	`true`,
+	`false`,
+	`{}`,
+	`[]`,
+	`[1,"a"]`,
+}
+
+// profileTypes are the columns in the profile truth table
+var profileTypes = []struct {
+	name string
+	new  func() interface{}
+}{
+	{"String", func() interface{} { return &ft.String{} }},
+	{"Int", func() interface{} { return &ft.Int{} }},
+	{"Float", func() interface{} { return &ft.Float{} }},
+	{"Bool", func() interface{} { return &ft.Bool{} }},
+	{"NString", func() interface{} { return &ft.NString{} }},
+	{"NInt", func() interface{} { return &ft.NInt{} }},
+	{"NFloat", func() interface{} { return &ft.NFloat{} }},
+	{"NBool", func() interface{} { return &ft.NBool{} }},
+}
+
+// truthTable renders the result of decoding each input with the policy.
+// Values are marshaled to JSON, errors are shown as "error"
+func truthTable(p ft.Policy) string {
+	rows := [][]string{{"input"}}
+	for _, pt := range profileTypes {
+		rows[0] = append(rows[0], pt.name)
+	}
+	for _, input := range profileInputs {
+		row := []string{input}
+		for _, pt := range profileTypes {
+			v := pt.new()
+			cell := "error"
+			if err := ft.Unmarshal([]byte(input), v, p); err == nil {
+				b, err := json.Marshal(v)
+				if err != nil {
+					panic(err)
+				}
+				cell = string(b)
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	buf := strings.Builder{}
+	for _, row := range rows {
+		cells := []string{}
+		for i, cell := range row {
+			cells = append(cells, fmt.Sprintf("%-*s", widths[i], cell))
+		}
+		buf.WriteString(strings.TrimRight(strings.Join(cells, " | "), " "))
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+func TestProfiles(t *testing.T) {
+	is := is.New(t)
+
+	profiles := []struct {
+		name   string
+		policy ft.Policy
+	}{
+		{"Default", ft.Policy{}},
+		{"ProfileStrict", ft.ProfileStrict()},
+		{"ProfileJS", ft.ProfileJS()},
+		{"ProfilePHP", ft.ProfilePHP()},
+		{"ProfilePython", ft.ProfilePython()},
+	}
+	buf := strings.Builder{}
+	for i, profile := range profiles {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("# " + profile.name + "\n\n")
+		buf.WriteString(truthTable(profile.policy))
+	}
+
+	golden := "testdata/profiles.golden"
+	if *update {
+		err := os.WriteFile(golden, []byte(buf.String()), 0644)
+		is.NoErr(err)
+	}
+	b, err := os.ReadFile(golden)
+	is.NoErr(err)
+	is.Equal(string(b), buf.String()) // Truth table must match golden file
+}
+
+func TestProfileLanguages(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		policy ft.Policy
+		input  string
+		v      interface{}
+		want   interface{}
+	}{
+		// Number("") and (int)"" are 0
+		{ft.ProfileJS(), `""`, &ft.Int{}, ft.IntFrom(0)},
+		{ft.ProfilePHP(), `""`, &ft.Int{}, ft.IntFrom(0)},
+		{ft.ProfileJS(), `" "`, &ft.NFloat{}, ft.NFloatFrom(0)},
+		{ft.ProfileJS(), `" "`, &ft.String{}, ft.StringFrom(" ")},
+		// (int)"abc" is 0, and numeric prefixes are used
+		{ft.ProfilePHP(), `"abc"`, &ft.Int{}, ft.IntFrom(0)},
+		{ft.ProfilePHP(), `"12abc"`, &ft.Int{}, ft.IntFrom(12)},
+		{ft.ProfilePHP(), `"1.5e3kg"`, &ft.Float{}, ft.FloatFrom(1500)},
+		{ft.ProfilePHP(), `"2.5"`, &ft.Int{}, ft.IntFrom(2)},
+		// int(2.5) is 2, and float("1e3") is 1000
+		{ft.ProfilePython(), `2.5`, &ft.Int{}, ft.IntFrom(2)},
+		{ft.ProfilePython(), `"1e3"`, &ft.Float{}, ft.FloatFrom(1000)},
+		{ft.ProfilePython(), `" 7 "`, &ft.Int{}, ft.IntFrom(7)},
+		// String([1, "a"]) is "1,a"
+		{ft.ProfileJS(), `[1, "a"]`, &ft.String{}, ft.StringFrom("1,a")},
+		// Null strings
+		{ft.ProfileJS(), `"undefined"`, &ft.NInt{}, ft.NInt{}},
+		{ft.ProfilePython(), `"None"`, &ft.NString{}, ft.NString{}},
+		{ft.ProfilePython(), `"None"`, &ft.String{}, ft.StringFrom("None")},
+	}
+	for _, tt := range tests {
+		err := ft.Unmarshal([]byte(tt.input), tt.v, tt.policy)
+		is.NoErr(err)
+		is.Equal(tt.want, reflect.ValueOf(tt.v).Elem().Interface()) // Value must match
+	}
+
+	// int("2.5"), int("1e3") and int("") are errors
+	for _, input := range []string{`"2.5"`, `"1e3"`, `""`, `"abc"`} {
+		err := ft.Unmarshal([]byte(input), &ft.Int{}, ft.ProfilePython())
+		is.True(err != nil) // Must not be an integer
+	}
+	// Number("0x1F") is 31, but ProfileJS does not enable Radix
+	err := ft.Unmarshal([]byte(`"0x1F"`), &ft.Int{}, ft.ProfileJS())
+	is.True(err != nil) // Radix is not enabled
+	p := ft.ProfileJS()
+	p.Radix = true
+	i := ft.Int{}
+	is.NoErr(ft.Unmarshal([]byte(`"0x1F"`), &i, p))
+	is.Equal(int64(31), i.Int64) // Value must match
+
+	// Profiles are not shared
+	p = ft.ProfilePHP()
+	p.FalseStrings[0] = "nope"
+	p.IntKinds = ft.KindNumber
+	b := ft.Bool{}
+	err = ft.Unmarshal([]byte(`""`), &b, ft.ProfilePHP())
+	is.NoErr(err)
+	is.Equal(false, b.Bool) // Value must match
+	err = ft.Unmarshal([]byte(`"1"`), &ft.Int{}, ft.ProfilePHP())
+	is.NoErr(err)
+}
+
+func TestProfileDefault(t *testing.T) {
+	is := is.New(t)
+
+	// The default profile must match UnmarshalJSON
+	for _, input := range profileInputs {
+		for _, pt := range profileTypes {
+			v1, v2 := pt.new(), pt.new()
+			err1 := json.Unmarshal([]byte(input), v1)
+			err2 := ft.Unmarshal([]byte(input), v2, ft.Policy{})
+			is.Equal(err1 == nil, err2 == nil) // Errors must match
+			is.Equal(v1, v2)                   // Values must match
+		}
+	}
+}
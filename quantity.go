@@ -0,0 +1,261 @@
+package ft
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DimensionMass   = "mass"
+	DimensionLength = "length"
+	DimensionVolume = "volume"
+)
+
+// Unit of measurement for a Quantity
+type Unit struct {
+	// Symbol is the canonical name of the unit, e.g. "kg"
+	Symbol string
+	// Dimension is the physical quantity measured, e.g. "mass"
+	Dimension string
+	// Factor converts a value in this unit to the base unit of the dimension.
+	// Units with the same dimension must use the same base unit
+	Factor float64
+}
+
+var (
+	unitsMu sync.RWMutex
+	units   = map[string]Unit{}
+)
+
+func init() {
+	for _, u := range []struct {
+		unit    Unit
+		aliases []string
+	}{
+		// Mass, base unit is the gram
+		{Unit{"mg", DimensionMass, 0.001}, []string{"milligram", "milligrams"}},
+		{Unit{"g", DimensionMass, 1}, []string{"gram", "grams"}},
+		{Unit{"kg", DimensionMass, 1000}, []string{"kgs", "kilogram", "kilograms"}},
+		{Unit{"t", DimensionMass, 1000000}, []string{"tonne", "tonnes"}},
+		{Unit{"oz", DimensionMass, 28.349523125}, []string{"ounce", "ounces"}},
+		{Unit{"lb", DimensionMass, 453.59237}, []string{"lbs", "pound", "pounds"}},
+		// Length, base unit is the metre
+		{Unit{"mm", DimensionLength, 0.001}, []string{"millimeter", "millimeters", "millimetre", "millimetres"}},
+		{Unit{"cm", DimensionLength, 0.01}, []string{"centimeter", "centimeters", "centimetre", "centimetres"}},
+		{Unit{"m", DimensionLength, 1}, []string{"meter", "meters", "metre", "metres"}},
+		{Unit{"km", DimensionLength, 1000}, []string{"kilometer", "kilometers", "kilometre", "kilometres"}},
+		{Unit{"in", DimensionLength, 0.0254}, []string{"inch", "inches"}},
+		{Unit{"ft", DimensionLength, 0.3048}, []string{"foot", "feet"}},
+		{Unit{"yd", DimensionLength, 0.9144}, []string{"yard", "yards"}},
+		{Unit{"mi", DimensionLength, 1609.344}, []string{"mile", "miles"}},
+		// Volume, base unit is the litre
+		{Unit{"ml", DimensionVolume, 0.001}, []string{"milliliter", "milliliters", "millilitre", "millilitres"}},
+		{Unit{"l", DimensionVolume, 1}, []string{"liter", "liters", "litre", "litres"}},
+	} {
+		if err := RegisterUnit(u.unit, u.aliases...); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterUnit adds a unit, and optional aliases for it, to the registry.
+// Names are matched case-insensitively
+func RegisterUnit(u Unit, aliases ...string) error {
+	if u.Symbol == "" || u.Dimension == "" {
+		return errors.Errorf("unit symbol and dimension must be set")
+	}
+	if u.Factor <= 0 {
+		return errors.Errorf("unit %q factor must be positive", u.Symbol)
+	}
+	unitsMu.Lock()
+	defer unitsMu.Unlock()
+	for _, name := range append([]string{u.Symbol}, aliases...) {
+		key := strings.ToLower(name)
+		if existing, ok := units[key]; ok && existing != u {
+			return errors.Errorf(
+				"unit %q is already registered as %q", name, existing.Symbol)
+		}
+		units[key] = u
+	}
+	return nil
+}
+
+// LookupUnit returns the registered unit for the given symbol or alias
+func LookupUnit(name string) (u Unit, ok bool) {
+	unitsMu.RLock()
+	defer unitsMu.RUnlock()
+	u, ok = units[strings.ToLower(strings.TrimSpace(name))]
+	return u, ok
+}
+
+// Quantity can be used to decode a physical quantity from JSON like
+// "10kg", "10 kg", {"value": 10, "unit": "kg"}, or a bare number.
+// The canonical unit is set with Policy.Unit, or the unit struct tag
+// directive, e.g. `ft:"unit=kg"`. Values are converted to it,
+// and bare numbers are assumed to be in it. Without a canonical unit
+// the decoded unit is kept. The numeric part is coerced like ft.Float
+type Quantity struct {
+	Value float64
+	Unit  string
+}
+
+func QuantityFrom(value float64, unit string) Quantity {
+	return Quantity{Value: value, Unit: unit}
+}
+
+// Convert returns the quantity in the given unit.
+// Errors if the units are unknown or measure different dimensions
+func (fq Quantity) Convert(unit string) (Quantity, error) {
+	from, ok := LookupUnit(fq.Unit)
+	if !ok {
+		return Quantity{}, errors.Errorf("unknown unit %q", fq.Unit)
+	}
+	to, ok := LookupUnit(unit)
+	if !ok {
+		return Quantity{}, errors.Errorf("unknown unit %q", unit)
+	}
+	if from.Dimension != to.Dimension {
+		return Quantity{}, errors.Errorf(
+			"incompatible units %q (%s) and %q (%s)",
+			fq.Unit, from.Dimension, unit, to.Dimension)
+	}
+	if from == to {
+		return QuantityFrom(fq.Value, to.Symbol), nil
+	}
+	return QuantityFrom(fq.Value*from.Factor/to.Factor, to.Symbol), nil
+}
+
+// String returns the value followed by the unit, e.g. "10 kg"
+func (fq Quantity) String() string {
+	s := strconv.FormatFloat(fq.Value, 'f', -1, 64)
+	if fq.Unit == "" {
+		return s
+	}
+	return s + " " + fq.Unit
+}
+
+// MarshalJSON method for Quantity
+func (fq Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Value Float  `json:"value"`
+		Unit  string `json:"unit"`
+	}{FloatFrom(fq.Value), fq.Unit})
+}
+
+// UnmarshalJSON method for Quantity
+func (fq *Quantity) UnmarshalJSON(bArr []byte) (err error) {
+	return fq.unmarshalJSON(bArr, &defaultPolicy)
+}
+
+func (fq *Quantity) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	s := ""
+	obj := struct {
+		Value json.RawMessage `json:"value"`
+		Unit  NString         `json:"unit"`
+	}{}
+
+	// Value is null
+	if string(bArr) == "null" {
+		*fq = Quantity{}
+		return
+	}
+
+	// Value is a...
+	// string
+	if err = json.Unmarshal(bArr, &s); err == nil {
+		return fq.parse(s, p)
+	}
+
+	// object
+	if kindOf(bArr) == KindObject {
+		if err = json.Unmarshal(bArr, &obj); err != nil {
+			return err
+		}
+		f := float64(0)
+		if len(obj.Value) > 0 {
+			if f, _, err = coerceFloat(obj.Value, p, false); err != nil {
+				return err
+			}
+		}
+		return fq.set(f, obj.Unit.String, p)
+	}
+
+	// number or bool, coerced like ft.Float
+	f, _, err := coerceFloat(bArr, p, false)
+	if err != nil {
+		return err
+	}
+	return fq.set(f, "", p)
+}
+
+// parse a string like "10kg" or "10 kg"
+func (fq *Quantity) parse(s string, p *Policy) error {
+	s = strings.TrimSpace(s)
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		if (c >= '0' && c <= '9') || c == '.' || c == '+' || c == '-' {
+			continue
+		}
+		// Exponent must be followed by a digit or sign
+		if (c == 'e' || c == 'E') && i+1 < len(s) && i > 0 &&
+			strings.IndexByte("0123456789+-", s[i+1]) >= 0 {
+			continue
+		}
+		break
+	}
+
+	// The numeric part is a JSON number if possible,
+	// otherwise it's coerced as a string
+	b := []byte(s[:i])
+	if !isJSONNumber(b) {
+		var err error
+		if b, err = json.Marshal(s[:i]); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	f, _, err := coerceFloat(b, p, false)
+	if err != nil {
+		return err
+	}
+	return fq.set(f, strings.TrimSpace(s[i:]), p)
+}
+
+// set the value, converting it to the canonical unit of the policy
+// if there is one
+func (fq *Quantity) set(value float64, unit string, p *Policy) error {
+	canonical := p.Unit
+	if unit == "" {
+		if canonical == "" {
+			return errors.Errorf("quantity %v has no unit", value)
+		}
+		unit = canonical
+	}
+	u, ok := LookupUnit(unit)
+	if !ok {
+		return errors.Errorf("unknown unit %q", unit)
+	}
+
+	q := QuantityFrom(value, u.Symbol)
+	if canonical != "" {
+		var err error
+		q, err = q.Convert(canonical)
+		if err != nil {
+			return err
+		}
+	}
+	*fq = q
+	return nil
+}
+
+func (fq Quantity) MarshalText() (text []byte, err error) {
+	return []byte(fq.String()), nil
+}
+
+func (fq *Quantity) UnmarshalText(text []byte) error {
+	return fq.parse(string(text), &defaultPolicy)
+}
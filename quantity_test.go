@@ -0,0 +1,166 @@
+package ft_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestUnmarshalQuantity(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		// Canonical unit for the field
+		Weight ft.Quantity `json:"weight" ft:"unit=kg"`
+	}
+	d := Data{}
+
+	// string
+	b := []byte(`{"weight": "10kg"}`)
+	err := ft.Unmarshal(b, &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(10, "kg"), d.Weight) // Value must match
+
+	b = []byte(`{"weight": "10 KG"}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(10, "kg"), d.Weight) // Value must match
+
+	b = []byte(`{"weight": "10000 g"}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(10, "kg"), d.Weight) // Value must match
+
+	b = []byte(`{"weight": "1.5e3g"}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(1.5, "kg"), d.Weight) // Value must match
+
+	b = []byte(`{"weight": "10 m"}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.Equal(`incompatible units "m" (length) and "kg" (mass)`, err.Error())
+
+	b = []byte(`{"weight": "10 stone"}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.Equal(`unknown unit "stone"`, err.Error())
+
+	b = []byte(`{"weight": "abc kg"}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.Equal("strconv.ParseFloat: parsing \"\": invalid syntax", err.Error())
+
+	// object
+	b = []byte(`{"weight": {"value": "2000", "unit": "g"}}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(2, "kg"), d.Weight) // Value must match
+
+	// number uses the canonical unit
+	b = []byte(`{"weight": 3.5}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(3.5, "kg"), d.Weight) // Value must match
+
+	// bool
+	b = []byte(`{"weight": true}`)
+	err = ft.Unmarshal(b, &d, ft.Policy{})
+	is.Equal("value is a bool", err.Error())
+
+	// null
+	err = ft.Unmarshal([]byte(`{"weight": null}`), &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.Quantity{}, d.Weight) // Must be zero
+
+	// Without a canonical unit the decoded unit is kept,
+	// the previous value is not used
+	q := ft.Quantity{}
+	err = ft.Unmarshal([]byte(`"10 lb"`), &q, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(10, "lb"), q) // Value must match
+	err = ft.Unmarshal([]byte(`"1 kg"`), &q, ft.Policy{})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(1, "kg"), q) // Value must match
+	err = json.Unmarshal([]byte(`"16 oz"`), &q)
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(16, "oz"), q) // Value must match
+	err = ft.Unmarshal([]byte(`10`), &q, ft.Policy{})
+	is.Equal("quantity 10 has no unit", err.Error())
+
+	// Policy
+	err = ft.Unmarshal([]byte(`"1 lb"`), &q, ft.Policy{Unit: "g"})
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(453.59237, "g"), q) // Value must match
+
+	// Slice and map elements
+	type Lists struct {
+		Lengths []ft.Quantity          `json:"lengths" ft:"unit=cm"`
+		Volumes map[string]ft.Quantity `json:"volumes" ft:"unit=millilitres"`
+	}
+	l := Lists{}
+	err = ft.Unmarshal([]byte(`{
+		"lengths": ["1 m", 5, {"value": 20, "unit": "mm"}],
+		"volumes": {"cup": "0.25 l"}
+	}`), &l, ft.Policy{})
+	is.NoErr(err)
+	is.Equal([]ft.Quantity{
+		ft.QuantityFrom(100, "cm"),
+		ft.QuantityFrom(5, "cm"),
+		ft.QuantityFrom(2, "cm"),
+	}, l.Lengths) // Values must match
+	is.Equal(ft.QuantityFrom(250, "ml"), l.Volumes["cup"]) // Value must match
+
+	type Unknown struct {
+		Weight ft.Quantity `json:"weight" ft:"unit=furlong"`
+	}
+	err = ft.Unmarshal([]byte(`{}`), &Unknown{}, ft.Policy{})
+	is.Equal(`ft: invalid tag on field ft_test.Unknown.Weight: unknown unit "furlong"`,
+		err.Error())
+}
+
+func TestConvertQuantity(t *testing.T) {
+	is := is.New(t)
+
+	q, err := ft.QuantityFrom(1, "km").Convert("m")
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(1000, "m"), q)
+
+	q, err = ft.QuantityFrom(1, "lb").Convert("g")
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(453.59237, "g"), q)
+
+	// Custom units
+	err = ft.RegisterUnit(ft.Unit{
+		Symbol: "st", Dimension: ft.DimensionMass, Factor: 6350.29318},
+		"stone")
+	is.NoErr(err)
+	q, err = ft.QuantityFrom(1, "stone").Convert("st")
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(1, "st"), q)
+
+	err = ft.RegisterUnit(ft.Unit{
+		Symbol: "m", Dimension: ft.DimensionLength, Factor: 2})
+	is.Equal(`unit "m" is already registered as "m"`, err.Error())
+}
+
+func TestMarshalQuantity(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Length ft.Quantity `json:"length"`
+	}
+	d := Data{Length: ft.QuantityFrom(1.5, "m")}
+	b, err := json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"length":{"value":1.5,"unit":"m"}}`, string(b))
+
+	// Round trip
+	d = Data{}
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.QuantityFrom(1.5, "m"), d.Length) // Value must match
+
+	text, err := d.Length.MarshalText()
+	is.NoErr(err)
+	is.Equal("1.5 m", string(text))
+}
@@ -0,0 +1,72 @@
+package ft
+
+import (
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Quote is a bit set of options for QuoteJSON
+type Quote uint8
+
+const (
+	// QuoteHTML escapes <, > and & as \u003c, \u003e and \u0026,
+	// so the JSON is safe to embed in HTML
+	QuoteHTML Quote = 1 << iota
+	// QuoteClean removes non-graphic characters, except newline and tab,
+	// instead of escaping them, see Clean
+	QuoteClean
+)
+
+const hex = "0123456789abcdef"
+
+// QuoteJSON returns s as a JSON string. Characters that are not printable,
+// e.g. control characters, U+00A0 and U+2028, are escaped as \uXXXX.
+// Invalid UTF-8 is replaced with U+FFFD.
+// String and NString use QuoteJSON without options to marshal JSON
+func QuoteJSON(s string, q Quote) []byte {
+	if q&QuoteClean != 0 {
+		s = Clean(s)
+	}
+
+	b := make([]byte, 0, len(s)+2)
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// Invalid UTF-8
+			b = append(b, `\ufffd`...)
+		case r == '"' || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r == '\n':
+			b = append(b, `\n`...)
+		case r == '\r':
+			b = append(b, `\r`...)
+		case r == '\t':
+			b = append(b, `\t`...)
+		case r == '\b':
+			b = append(b, `\b`...)
+		case r == '\f':
+			b = append(b, `\f`...)
+		case q&QuoteHTML != 0 && (r == '<' || r == '>' || r == '&'):
+			b = appendEscape(b, r)
+		case r == ' ' || unicode.IsPrint(r):
+			b = utf8.AppendRune(b, r)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			b = appendEscape(appendEscape(b, r1), r2)
+		default:
+			b = appendEscape(b, r)
+		}
+	}
+	return append(b, '"')
+}
+
+// appendEscape appends r, that must not exceed 0xFFFF, as \uXXXX
+func appendEscape(b []byte, r rune) []byte {
+	return append(b, '\\', 'u',
+		hex[r>>12&0xF], hex[r>>8&0xF], hex[r>>4&0xF], hex[r&0xF])
+}
@@ -0,0 +1,56 @@
+package ft_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestQuoteJSON(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		s    string
+		q    ft.Quote
+		want string
+	}{
+		{"foo", 0, `"foo"`},
+		{`say "hi" \o/`, 0, `"say \"hi\" \\o/"`},
+		{"a\nb\rc\td\be\ff", 0, `"a\nb\rc\td\be\ff"`},
+		{"\u0000\u0002\u001f\u007f", 0, `"\u0000\u0002\u001f\u007f"`},
+		{"line\u2028para\u2029", 0, `"line\u2028para\u2029"`},
+		{"nbsp\u00a0", 0, `"nbsp\u00a0"`},
+		{"héllo 世界 🙂", 0, `"héllo 世界 🙂"`},
+		{"\U000e0001", 0, `"\udb40\udc01"`},
+		{"bad\xffutf8\xc3", 0, `"bad\ufffdutf8\ufffd"`},
+		{"<a href='x'>&</a>", 0, `"<a href='x'>&</a>"`},
+		{"<a href='x'>&</a>", ft.QuoteHTML,
+			`"\u003ca href='x'\u003e\u0026\u003c/a\u003e"`},
+		{"foo\u0002bar\tbaz\n", ft.QuoteClean, `"foobar\tbaz\n"`},
+	}
+	for _, tt := range tests {
+		b := ft.QuoteJSON(tt.s, tt.q)
+		is.Equal(tt.want, string(b)) // Value must match
+		is.True(json.Valid(b))       // Must be valid JSON
+
+		// Must round trip, except for invalid UTF-8 and cleaned strings
+		s := ""
+		err := json.Unmarshal(b, &s)
+		is.NoErr(err)
+		if tt.q&ft.QuoteClean == 0 && tt.s != "bad\xffutf8\xc3" {
+			is.Equal(tt.s, s) // Must round trip
+		}
+	}
+
+	// MarshalJSON is lossless
+	for _, s := range []string{"foo\u0002bar", "a\u2028b", "x\xffy"} {
+		b, err := ft.StringFrom(s).MarshalJSON()
+		is.NoErr(err)
+		is.True(json.Valid(b)) // Must be valid JSON
+		b, err = ft.NStringFrom(s).MarshalJSON()
+		is.NoErr(err)
+		is.True(json.Valid(b)) // Must be valid JSON
+	}
+}
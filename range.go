@@ -0,0 +1,341 @@
+package ft
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Range can be used to decode a numeric range from JSON like
+// "10-20", "10..20", ">=10", "<20", [10, 20] or {"min": 10, "max": 20}.
+// Bounds are inclusive unless the exclusive flag is set,
+// a bound that is not valid means the range is open on that side.
+// Range strings must have at least one bound, so ".." is an error
+type Range struct {
+	Min          NFloat
+	Max          NFloat
+	MinExclusive bool
+	MaxExclusive bool
+}
+
+// RangeFrom returns an inclusive range
+func RangeFrom(min, max float64) Range {
+	return Range{Min: NFloatFrom(min), Max: NFloatFrom(max)}
+}
+
+// ParseRange returns a Range for the given string,
+// bounds are coerced like ft.Float with the default policy
+func ParseRange(s string) (fr Range, err error) {
+	return parseRange(s, &defaultPolicy)
+}
+
+// parseRange returns a Range for the given string,
+// bounds are coerced like ft.Float as per the policy
+func parseRange(s string, p *Policy) (fr Range, err error) {
+	in := s
+	s = strings.TrimSpace(s)
+	if strings.IndexAny(s, "<>=") == 0 {
+		fr, err = parseRangeComparators(s, p)
+	} else {
+		fr, err = parseRangeInterval(s, p)
+	}
+	if err == nil && !fr.Min.Valid && !fr.Max.Valid {
+		err = errors.Errorf("expected a bound")
+	}
+	if err != nil {
+		return Range{}, errors.Errorf("invalid range %q: %s", in, err)
+	}
+	if err = fr.Validate(); err != nil {
+		return Range{}, err
+	}
+	return fr, nil
+}
+
+var (
+	rangeComparator = regexp.MustCompile(`^(>=|<=|>|<|=)\s*`)
+	rangeSeparator  = regexp.MustCompile(`^\s*(\.\.|-|–|to)\s*`)
+)
+
+// rangeBound coerces a bound of a range string like ft.Float,
+// the bound is open if s is empty
+func rangeBound(s string, p *Policy) (NFloat, error) {
+	if strings.TrimSpace(s) == "" {
+		return NFloat{}, nil
+	}
+	f := Float{}
+	if err := f.unmarshalText([]byte(s), p); err != nil {
+		return NFloat{}, err
+	}
+	return NFloatFrom(f.Float64), nil
+}
+
+// parseRangeComparators parses clauses like ">=10 <20" or ">10, <=20"
+func parseRangeComparators(s string, p *Policy) (fr Range, err error) {
+	for s != "" {
+		op := rangeComparator.FindStringSubmatch(s)
+		if op == nil {
+			return fr, errors.Errorf("expected comparator at %q", s)
+		}
+		// The bound ends at the next clause
+		n := s[len(op[0]):]
+		s = ""
+		if i := strings.IndexAny(n, "<>="); i >= 0 {
+			n, s = n[:i], n[i:]
+		}
+		b, err := rangeBound(strings.TrimRight(n, " ,"), p)
+		if err != nil {
+			return fr, err
+		}
+		if !b.Valid {
+			return fr, errors.Errorf("expected number after %q", op[1])
+		}
+		switch op[1] {
+		case ">=", ">":
+			if fr.Min.Valid {
+				return fr, errors.Errorf("lower bound is repeated")
+			}
+			fr.Min, fr.MinExclusive = b, op[1] == ">"
+		case "<=", "<":
+			if fr.Max.Valid {
+				return fr, errors.Errorf("upper bound is repeated")
+			}
+			fr.Max, fr.MaxExclusive = b, op[1] == "<"
+		case "=":
+			if fr.Min.Valid || fr.Max.Valid {
+				return fr, errors.Errorf("bounds are repeated")
+			}
+			fr.Min, fr.Max = b, b
+		}
+	}
+	return fr, nil
+}
+
+// parseRangeInterval parses "10-20", "10..20", "10..", "..20" or "10".
+// The bounds are split at the first separator where both sides are
+// numbers or empty, so a leading "-" is a sign, e.g. "-5--1.5"
+func parseRangeInterval(s string, p *Policy) (fr Range, err error) {
+	var sepErr error
+	for i := range s {
+		sep := rangeSeparator.FindString(s[i:])
+		if sep == "" || (i == 0 && strings.TrimSpace(sep) != "..") {
+			continue
+		}
+		fr.Min, err = rangeBound(s[:i], p)
+		if err == nil {
+			fr.Max, err = rangeBound(s[i+len(sep):], p)
+		}
+		if err == nil {
+			return fr, nil
+		}
+		if sepErr == nil {
+			sepErr = err
+		}
+	}
+
+	// A single number
+	b, err := rangeBound(s, p)
+	switch {
+	case err == nil:
+		return Range{Min: b, Max: b}, nil
+	case sepErr != nil:
+		return Range{}, sepErr
+	}
+	return Range{}, errors.Errorf("expected separator at %q", s)
+}
+
+// Validate returns an error if a bound is NaN, if min is greater than max,
+// or if the range is empty. Infinite bounds are allowed
+func (fr Range) Validate() error {
+	if (fr.Min.Valid && math.IsNaN(fr.Min.Float64)) ||
+		(fr.Max.Valid && math.IsNaN(fr.Max.Float64)) {
+		return errors.Errorf("range bound is NaN")
+	}
+	if !fr.Min.Valid || !fr.Max.Valid {
+		return nil
+	}
+	if fr.Min.Float64 > fr.Max.Float64 {
+		return errors.Errorf("range min %v is greater than max %v",
+			fr.Min.Float64, fr.Max.Float64)
+	}
+	if fr.Min.Float64 == fr.Max.Float64 &&
+		(fr.MinExclusive || fr.MaxExclusive) {
+		return errors.Errorf("range %s is empty", fr)
+	}
+	return nil
+}
+
+// Contains returns true if f is in the range
+func (fr Range) Contains(f float64) bool {
+	if fr.Min.Valid {
+		if f < fr.Min.Float64 || (fr.MinExclusive && f == fr.Min.Float64) {
+			return false
+		}
+	}
+	if fr.Max.Valid {
+		if f > fr.Max.Float64 || (fr.MaxExclusive && f == fr.Max.Float64) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns "min..max" if both bounds are inclusive,
+// otherwise comparators are used, e.g. ">10 <=20"
+func (fr Range) String() string {
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if !fr.MinExclusive && !fr.MaxExclusive {
+		s := ".."
+		if fr.Min.Valid {
+			s = format(fr.Min.Float64) + s
+		}
+		if fr.Max.Valid {
+			s += format(fr.Max.Float64)
+		}
+		return s
+	}
+	clauses := []string{}
+	if fr.Min.Valid {
+		op := ">="
+		if fr.MinExclusive {
+			op = ">"
+		}
+		clauses = append(clauses, op+format(fr.Min.Float64))
+	}
+	if fr.Max.Valid {
+		op := "<="
+		if fr.MaxExclusive {
+			op = "<"
+		}
+		clauses = append(clauses, op+format(fr.Max.Float64))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// rangeJSON is the canonical JSON representation of a Range
+type rangeJSON struct {
+	Min          NFloat `json:"min"`
+	Max          NFloat `json:"max"`
+	MinExclusive bool   `json:"min_exclusive"`
+	MaxExclusive bool   `json:"max_exclusive"`
+}
+
+// MarshalJSON method for Range
+func (fr Range) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rangeJSON(fr))
+}
+
+// UnmarshalJSON method for Range
+func (fr *Range) UnmarshalJSON(bArr []byte) (err error) {
+	return fr.unmarshalJSON(bArr, &defaultPolicy)
+}
+
+func (fr *Range) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	s, arr := "", []json.RawMessage{}
+	obj := struct {
+		Min          json.RawMessage `json:"min"`
+		Max          json.RawMessage `json:"max"`
+		MinExclusive json.RawMessage `json:"min_exclusive"`
+		MaxExclusive json.RawMessage `json:"max_exclusive"`
+	}{}
+
+	// Value is null
+	if string(bArr) == "null" {
+		*fr = Range{}
+		return
+	}
+
+	// Value is a...
+	// string
+	if err = json.Unmarshal(bArr, &s); err == nil {
+		r, err := parseRange(s, p)
+		if err != nil {
+			return err
+		}
+		*fr = r
+		return nil
+	}
+
+	r := Range{}
+	switch kindOf(bArr) {
+	// array
+	case KindArray:
+		if err = json.Unmarshal(bArr, &arr); err != nil {
+			return err
+		}
+		if len(arr) != 2 {
+			return errors.Errorf(
+				"range array must have 2 elements, found %d", len(arr))
+		}
+		if err = r.Min.unmarshalJSON(arr[0], p); err != nil {
+			return err
+		}
+		if err = r.Max.unmarshalJSON(arr[1], p); err != nil {
+			return err
+		}
+
+	// object, missing members are null
+	case KindObject:
+		if err = json.Unmarshal(bArr, &obj); err != nil {
+			return err
+		}
+		if len(obj.Min) > 0 {
+			if err = r.Min.unmarshalJSON(obj.Min, p); err != nil {
+				return err
+			}
+		}
+		if len(obj.Max) > 0 {
+			if err = r.Max.unmarshalJSON(obj.Max, p); err != nil {
+				return err
+			}
+		}
+		if len(obj.MinExclusive) > 0 {
+			r.MinExclusive, _, err = coerceBool(obj.MinExclusive, p, true)
+			if err != nil {
+				return err
+			}
+		}
+		if len(obj.MaxExclusive) > 0 {
+			r.MaxExclusive, _, err = coerceBool(obj.MaxExclusive, p, true)
+			if err != nil {
+				return err
+			}
+		}
+
+	// number, coerced like ft.Float
+	default:
+		f, _, err := coerceFloat(bArr, p, false)
+		if err != nil {
+			return err
+		}
+		r = RangeFrom(f, f)
+	}
+
+	if err = r.Validate(); err != nil {
+		return err
+	}
+	*fr = r
+	return nil
+}
+
+func (fr Range) MarshalText() (text []byte, err error) {
+	return []byte(fr.String()), nil
+}
+
+func (fr *Range) UnmarshalText(text []byte) error {
+	return fr.unmarshalText(text, &defaultPolicy)
+}
+
+func (fr *Range) unmarshalText(text []byte, p *Policy) error {
+	r, err := parseRange(string(text), p)
+	if err != nil {
+		return err
+	}
+	*fr = r
+	return nil
+}
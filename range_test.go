@@ -0,0 +1,209 @@
+package ft_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestUnmarshalRange(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Range ft.Range `json:"range"`
+	}
+	d := Data{}
+
+	// null
+	b := []byte(`{"range": null}`)
+	err := json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Range{}, d.Range) // Range must be unbounded
+
+	// string
+	for _, s := range []string{"10-20", "10..20", "10 - 20", "10 to 20"} {
+		d = Data{}
+		b = []byte(`{"range": "` + s + `"}`)
+		err = json.Unmarshal(b, &d)
+		is.NoErr(err)
+		is.Equal(ft.RangeFrom(10, 20), d.Range) // Value must match
+	}
+
+	b = []byte(`{"range": "-5--1.5"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.RangeFrom(-5, -1.5), d.Range) // Value must match
+
+	b = []byte(`{"range": ">=10"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Range{Min: ft.NFloatFrom(10)}, d.Range) // Value must match
+
+	b = []byte(`{"range": ">10, <=20"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Range{
+		Min: ft.NFloatFrom(10), Max: ft.NFloatFrom(20),
+		MinExclusive: true}, d.Range) // Value must match
+
+	b = []byte(`{"range": "..20"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Range{Max: ft.NFloatFrom(20)}, d.Range) // Value must match
+
+	b = []byte(`{"range": "20-10"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("range min 20 is greater than max 10", err.Error())
+
+	b = []byte(`{"range": ">10 <10"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("range >10 <10 is empty", err.Error())
+
+	b = []byte(`{"range": "abc"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal(`invalid range "abc": expected separator at "abc"`, err.Error())
+
+	// A range string must have a bound
+	for _, s := range []string{"", " ", ".."} {
+		_, err = ft.ParseRange(s)
+		is.Equal(`invalid range "`+s+`": expected a bound`, err.Error())
+	}
+
+	// Bounds are coerced like ft.Float
+	r, err := ft.ParseRange("-Infinity..1e3")
+	is.NoErr(err)
+	is.Equal(ft.Range{
+		Min: ft.NFloatFrom(math.Inf(-1)), Max: ft.NFloatFrom(1000)},
+		r) // Value must match
+	_, err = ft.ParseRange("10-abc")
+	is.Equal(`invalid range "10-abc": `+
+		`strconv.ParseFloat: parsing "abc": invalid syntax`, err.Error())
+	p := ft.Policy{Locale: ft.LocaleDE}
+	err = ft.Unmarshal([]byte(`{"range": "1.000,5..2.000"}`), &d, p)
+	is.NoErr(err)
+	is.Equal(ft.RangeFrom(1000.5, 2000), d.Range) // Value must match
+	err = ft.Unmarshal([]byte(`{"range": ">1,5, <=2"}`), &d, p)
+	is.NoErr(err)
+	is.Equal(ft.Range{
+		Min: ft.NFloatFrom(1.5), Max: ft.NFloatFrom(2),
+		MinExclusive: true}, d.Range) // Value must match
+	p = ft.Policy{NonFinite: ft.NonFiniteReject}
+	err = ft.Unmarshal([]byte(`{"range": "0..Infinity"}`), &d, p)
+	is.Equal(`invalid range "0..Infinity": `+
+		`value "Infinity" is not a finite number`, err.Error())
+	m := map[ft.Range]bool{}
+	err = ft.Unmarshal([]byte(`{"1,5-2": true}`), &m, p)
+	is.True(err != nil) // Locale must be set
+	err = ft.Unmarshal([]byte(`{"1,5-2": true}`), &m, ft.Policy{Locale: ft.LocaleFR})
+	is.NoErr(err)
+	is.True(m[ft.RangeFrom(1.5, 2)]) // Key must match
+
+	// array
+	b = []byte(`{"range": [10, "20"]}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.RangeFrom(10, 20), d.Range) // Value must match
+
+	b = []byte(`{"range": [10, null]}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Range{Min: ft.NFloatFrom(10)}, d.Range) // Value must match
+
+	b = []byte(`{"range": [10]}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("range array must have 2 elements, found 1", err.Error())
+
+	// object
+	b = []byte(`{"range": {"min": 10, "max": 20, "max_exclusive": "true"}}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Range{
+		Min: ft.NFloatFrom(10), Max: ft.NFloatFrom(20),
+		MaxExclusive: true}, d.Range) // Value must match
+
+	b = []byte(`{"range": {"min": 30, "max": 20}}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("range min 30 is greater than max 20", err.Error())
+
+	// NaN bounds are rejected, infinite bounds are allowed
+	for _, s := range []string{
+		`["NaN", 10]`, `[0, "NaN"]`, `{"min": "NaN"}`, `{"max": "nan"}`,
+	} {
+		err = json.Unmarshal([]byte(`{"range": `+s+`}`), &d)
+		is.Equal("range bound is NaN", err.Error())
+	}
+	is.Equal("range bound is NaN",
+		ft.Range{Max: ft.NFloatFrom(math.NaN())}.Validate().Error())
+	b = []byte(`{"range": ["-Infinity", 0]}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Range{
+		Min: ft.NFloatFrom(math.Inf(-1)), Max: ft.NFloatFrom(0)},
+		d.Range) // Value must match
+
+	// number
+	b = []byte(`{"range": 15}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.RangeFrom(15, 15), d.Range) // Value must match
+
+	// bool
+	b = []byte(`{"range": true}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("value is a bool", err.Error())
+}
+
+func TestRangeContains(t *testing.T) {
+	is := is.New(t)
+
+	r := ft.RangeFrom(10, 20)
+	is.True(r.Contains(10))
+	is.True(r.Contains(20))
+	is.True(!r.Contains(9.99))
+	is.True(!r.Contains(20.01))
+
+	r.MinExclusive, r.MaxExclusive = true, true
+	is.True(!r.Contains(10))
+	is.True(!r.Contains(20))
+	is.True(r.Contains(15))
+
+	r = ft.Range{Min: ft.NFloatFrom(10)}
+	is.True(r.Contains(1e300))
+	is.True(!r.Contains(-1))
+}
+
+func TestMarshalRange(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Range ft.Range `json:"range"`
+	}
+	d := Data{Range: ft.RangeFrom(10, 20)}
+	b, err := json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"range":{"min":10,"max":20,"min_exclusive":false,"max_exclusive":false}}`,
+		string(b))
+
+	d.Range = ft.Range{Min: ft.NFloatFrom(10), MinExclusive: true}
+	b, err = json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"range":{"min":10,"max":null,"min_exclusive":true,"max_exclusive":false}}`,
+		string(b))
+
+	// Round trip
+	d2 := Data{}
+	err = json.Unmarshal(b, &d2)
+	is.NoErr(err)
+	is.Equal(d, d2) // Value must match
+
+	// Text
+	for _, s := range []string{"10..20", "10..", "..20", ">10 <=20", ">=10 <20"} {
+		r := ft.Range{}
+		err = r.UnmarshalText([]byte(s))
+		is.NoErr(err)
+		is.Equal(s, r.String()) // Text must round trip
+	}
+}
@@ -0,0 +1,146 @@
+package ft
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+)
+
+// Redacted replaces the value of secrets when marshaling or printing
+const Redacted = "[REDACTED]"
+
+// Secret can be used to decode any JSON value to string, like ft.String,
+// but the value is redacted by MarshalJSON, MarshalText, String,
+// and all fmt verbs. Call Reveal to read the value.
+// The value is stored behind a pointer, so printers that read unexported
+// fields by reflection, e.g. fmt printing a struct that contains the
+// secret in an unexported field, only see an address
+type Secret struct {
+	secret *string
+}
+
+func SecretFrom(s string) Secret {
+	return Secret{secret: &s}
+}
+
+// Reveal returns the secret value
+func (fs Secret) Reveal() string {
+	if fs.secret == nil {
+		return ""
+	}
+	return *fs.secret
+}
+
+// Equal compares secrets in constant time.
+// Values are hashed first, so the time taken does not leak the length
+func (fs Secret) Equal(other Secret) bool {
+	return fs.EqualString(other.Reveal())
+}
+
+// EqualString compares the secret with s in constant time
+func (fs Secret) EqualString(s string) bool {
+	a := sha256.Sum256([]byte(fs.Reveal()))
+	b := sha256.Sum256([]byte(s))
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// String returns Redacted
+func (fs Secret) String() string {
+	return Redacted
+}
+
+// GoString returns Redacted
+func (fs Secret) GoString() string {
+	return Redacted
+}
+
+// Format implements fmt.Formatter, all verbs print Redacted
+func (fs Secret) Format(f fmt.State, verb rune) {
+	_, _ = f.Write([]byte(Redacted))
+}
+
+// MarshalJSON method for Secret
+func (fs Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+// UnmarshalJSON method for Secret, the value is coerced like ft.String
+func (fs *Secret) UnmarshalJSON(bArr []byte) (err error) {
+	return fs.unmarshalJSON(bArr, &defaultPolicy)
+}
+
+func (fs *Secret) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	s, _, err := coerceString(bArr, p, false)
+	if err != nil {
+		return err
+	}
+	*fs = SecretFrom(s)
+	return
+}
+
+func (fs Secret) MarshalText() (text []byte, err error) {
+	return []byte(Redacted), nil
+}
+
+func (fs *Secret) UnmarshalText(text []byte) error {
+	return fs.unmarshalText(text, &defaultPolicy)
+}
+
+func (fs *Secret) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, false)
+	return fs.unmarshalJSON(bArr, tp)
+}
+
+// NSecret can be used to decode any JSON value to a secret string,
+// and allows null. See Secret
+type NSecret struct {
+	Secret
+	Valid bool
+}
+
+func NSecretFrom(s string) NSecret {
+	return NSecret{Secret: SecretFrom(s), Valid: true}
+}
+
+// MarshalJSON method for NSecret
+func (fs NSecret) MarshalJSON() ([]byte, error) {
+	if !fs.Valid {
+		return []byte(`null`), nil
+	}
+	return fs.Secret.MarshalJSON()
+}
+
+// UnmarshalJSON method for NSecret
+func (fs *NSecret) UnmarshalJSON(bArr []byte) (err error) {
+	return fs.unmarshalJSON(bArr, &defaultPolicy)
+}
+
+func (fs *NSecret) unmarshalJSON(bArr []byte, p *Policy) (err error) {
+	s, valid, err := coerceString(bArr, p, true)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		*fs = NSecret{}
+		return
+	}
+	*fs = NSecretFrom(s)
+	return
+}
+
+func (fs NSecret) MarshalText() (text []byte, err error) {
+	if !fs.Valid {
+		return []byte{}, nil
+	}
+	return fs.Secret.MarshalText()
+}
+
+func (fs *NSecret) UnmarshalText(text []byte) error {
+	return fs.unmarshalText(text, &defaultPolicy)
+}
+
+func (fs *NSecret) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, true)
+	return fs.unmarshalJSON(bArr, tp)
+}
@@ -0,0 +1,110 @@
+package ft_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestUnmarshalSecret(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Key  ft.Secret  `json:"key"`
+		NKey ft.NSecret `json:"nkey"`
+	}
+	d := Data{}
+
+	// null
+	b := []byte(`{"key": null, "nkey": null}`)
+	err := json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("", d.Key.Reveal())  // Value must match
+	is.Equal(false, d.NKey.Valid) // Must not be valid
+
+	// string
+	b = []byte(`{"key": "s3cr3t", "nkey": "abc"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("s3cr3t", d.Key.Reveal()) // Value must match
+	is.Equal(true, d.NKey.Valid)       // Must be valid
+	is.Equal("abc", d.NKey.Reveal())   // Value must match
+
+	// int, coerced like ft.String
+	b = []byte(`{"key": 1234, "nkey": 5678}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("1234", d.Key.Reveal())  // Value must match
+	is.Equal("5678", d.NKey.Reveal()) // Value must match
+}
+
+func TestRedactSecret(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Key  ft.Secret  `json:"key"`
+		NKey ft.NSecret `json:"nkey"`
+	}
+	d := Data{Key: ft.SecretFrom("s3cr3t"), NKey: ft.NSecretFrom("s3cr3t")}
+
+	b, err := json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"key":"[REDACTED]","nkey":"[REDACTED]"}`, string(b))
+
+	text, err := d.Key.MarshalText()
+	is.NoErr(err)
+	is.Equal(ft.Redacted, string(text))
+
+	// fmt verbs
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x"} {
+		s := fmt.Sprintf(verb, d)
+		is.True(!bytes.Contains([]byte(s), []byte("s3cr3t"))) // Must redact
+		s = fmt.Sprintf(verb, &d)
+		is.True(!bytes.Contains([]byte(s), []byte("s3cr3t"))) // Must redact
+	}
+	is.Equal(ft.Redacted, d.Key.String())
+	is.Equal(ft.Redacted, fmt.Sprint(d.NKey))
+
+	// Unexported fields are printed by reflection, without calling Format
+	type config struct {
+		pw  ft.Secret
+		npw ft.NSecret
+	}
+	cfg := config{pw: ft.SecretFrom("hunter2"), npw: ft.NSecretFrom("hunter2")}
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		s := fmt.Sprintf(verb, cfg)
+		is.True(!strings.Contains(s, "hunter2")) // Must not leak
+		s = fmt.Sprintf(verb, &cfg)
+		is.True(!strings.Contains(s, "hunter2")) // Must not leak
+	}
+	is.Equal("hunter2", cfg.pw.Reveal()) // Value must match
+
+	// Templates
+	buf := bytes.NewBufferString("")
+	tpl := template.Must(template.New("tpl").Parse(
+		`{{.Key}} {{.NKey}} {{.Key.Reveal}}`))
+	err = tpl.Execute(buf, d)
+	is.NoErr(err)
+	is.Equal("[REDACTED] [REDACTED] s3cr3t", buf.String())
+
+	// Invalid
+	b, err = json.Marshal(Data{})
+	is.NoErr(err)
+	is.Equal(`{"key":"[REDACTED]","nkey":null}`, string(b))
+}
+
+func TestEqualSecret(t *testing.T) {
+	is := is.New(t)
+
+	s := ft.SecretFrom("s3cr3t")
+	is.True(s.Equal(ft.SecretFrom("s3cr3t")))
+	is.True(!s.Equal(ft.SecretFrom("s3cr3")))
+	is.True(s.EqualString("s3cr3t"))
+	is.True(!s.EqualString(""))
+}
@@ -0,0 +1,323 @@
+package ft
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Struct tag directives are set with the "ft" key, for example
+//
+//	ID    ft.String `json:"id" ft:"strict"`
+//	Price ft.Float  `json:"price" ft:"trim,kinds=number|string,default=0"`
+//
+// Directives override the decoder Policy for the field,
+// and for ft values nested inside it. They are:
+//
+//	strict          only accept the natural JSON kind of each type,
+//	                e.g. strings for ft.String and numbers for ft.Int
+//	kinds=a|b       accepted JSON kinds, any of null, string, number,
+//	                bool, object and array
+//	composite=mode  how objects and arrays are coerced to string, one of
+//	                error, raw and join, see StringComposite
+//	normalise=a|b   normalisation steps for strings, any of strip, nfc,
+//	                nfkc, collapse and fold, see Normalise
+//	maxrunes=n      truncate strings to n runes
+//	round=mode      how numbers with a fractional part are converted to
+//	                integers, one of truncate, reject, strict, floor, ceil,
+//	                half_up and half_even, see FloatToInt
+//	overflow=mode   how numbers out of range are decoded, error or saturate
+//	nonfinite=mode  how strings like "NaN" are decoded, string, reject
+//	                or null, see NonFinite
+//	locale=name     locale of numeric strings, one of en, de, fr, ch
+//	                and auto, see Locale
+//	unit=symbol     canonical unit of ft.Quantity, e.g. kg, see Unit
+//	bools=mode      how strings are coerced to bool, strict or permissive
+//	nulls=a|b       strings that N-types decode as null, e.g. None|N/A,
+//	                see NullStrings
+//	empty=mode      how empty strings are decoded, one of keep, null,
+//	                zero and error, see EmptyString
+//	numeric=mode    how strings that are not JSON numbers are decoded,
+//	                one of json, integer, prefix and emptyzero,
+//	                see NumericStrings
+//	radix           accept integer strings like "0x1F" and "1_000", see Radix
+//	unwrap          decode single element arrays like the element
+//	trim            trim whitespace from strings before coercion
+//	notnull         null is an error, also for N-types
+//	default=value   used if the key is missing or the value is null.
+//	                JSON scalars are used as is, other values are strings.
+//	                Defaults are coerced as per the default policy
+//	alias=a|b       other keys for the field, e.g. sku_code|SKUCode
+//	keys=mode       how keys of nested objects are matched to fields,
+//	                one of fold, exact and style, see KeyMatch
+//
+// Tags are validated when a type is first decoded,
+// unknown directives are an error
+
+// tagOptions parsed from the ft struct tag
+type tagOptions struct {
+	directives []func(p *Policy)
+	def        json.RawMessage
+	aliases    []string
+}
+
+// apply returns a copy of p with the directives applied
+func (o *tagOptions) apply(p Policy) Policy {
+	for _, d := range o.directives {
+		d(&p)
+	}
+	return p
+}
+
+// tagDirectives maps directive names to whether they take a value
+var tagDirectives = map[string]bool{
+	"strict":    false,
+	"kinds":     true,
+	"round":     true,
+	"composite": true,
+	"normalise": true,
+	"maxrunes":  true,
+	"overflow":  true,
+	"nonfinite": true,
+	"locale":    true,
+	"unit":      true,
+	"bools":     true,
+	"nulls":     true,
+	"empty":     true,
+	"numeric":   true,
+	"radix":     false,
+	"unwrap":    false,
+	"trim":      false,
+	"notnull":   false,
+	"default":   true,
+	"alias":     true,
+	"keys":      true,
+}
+
+// parseKinds parses kind names separated by "|"
+func parseKinds(s string) (k Kind, err error) {
+	for _, name := range strings.Split(s, "|") {
+		found := false
+		for _, kn := range kindNames {
+			if kn.name == name {
+				k |= kn.kind
+				found = true
+			}
+		}
+		if !found {
+			return 0, errors.Errorf("unknown kind %q", name)
+		}
+	}
+	return k, nil
+}
+
+// parseNormalise parses normalisation step names separated by "|"
+func parseNormalise(s string) (n Normalise, err error) {
+	for _, name := range strings.Split(s, "|") {
+		found := false
+		for _, nn := range normaliseNames {
+			if nn.name == name {
+				n |= nn.step
+				found = true
+			}
+		}
+		if !found {
+			return 0, errors.Errorf("unknown normalise step %q", name)
+		}
+	}
+	return n, nil
+}
+
+// parseTag parses the ft struct tag, nil is returned if the tag is empty
+func parseTag(tag string) (*tagOptions, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	o := &tagOptions{}
+	for _, directive := range strings.Split(tag, ",") {
+		name, value := strings.TrimSpace(directive), ""
+		hasValue := false
+		if eq := strings.IndexByte(directive, '='); eq >= 0 {
+			name, value = strings.TrimSpace(directive[:eq]), directive[eq+1:]
+			hasValue = true
+		}
+		if takesValue, ok := tagDirectives[name]; !ok {
+			return nil, errors.Errorf("unknown directive %q", name)
+		} else if takesValue && !hasValue {
+			return nil, errors.Errorf("directive %q requires a value", name)
+		} else if !takesValue && hasValue {
+			return nil, errors.Errorf("directive %q does not take a value", name)
+		}
+
+		switch name {
+		case "strict":
+			o.directives = append(o.directives, func(p *Policy) {
+				p.StringKinds = KindString
+				p.IntKinds = KindNumber
+				p.FloatKinds = KindNumber
+				p.BoolKinds = KindBool
+			})
+
+		case "kinds":
+			k, err := parseKinds(value)
+			if err != nil {
+				return nil, err
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.StringKinds, p.IntKinds, p.FloatKinds, p.BoolKinds = k, k, k, k
+			})
+
+		case "nulls":
+			nulls := strings.Split(value, "|")
+			o.directives = append(o.directives, func(p *Policy) {
+				p.NullStrings = nulls
+			})
+
+		case "composite":
+			mode, ok := stringCompositeNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown composite mode %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.StringComposite = mode
+			})
+
+		case "normalise":
+			n, err := parseNormalise(value)
+			if err != nil {
+				return nil, err
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.Normalise = n
+			})
+
+		case "maxrunes":
+			max, err := strconv.Atoi(value)
+			if err != nil || max < 0 {
+				return nil, errors.Errorf("invalid maxrunes %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.MaxRunes = max
+			})
+
+		case "round":
+			mode, ok := floatToIntNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown round mode %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.FloatToInt = mode
+			})
+
+		case "overflow":
+			mode, ok := overflowNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown overflow mode %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.Overflow = mode
+			})
+
+		case "nonfinite":
+			mode, ok := nonFiniteNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown nonfinite mode %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.NonFinite = mode
+			})
+
+		case "locale":
+			l, ok := localeNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown locale %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.Locale = l
+			})
+
+		case "unit":
+			u, ok := LookupUnit(value)
+			if !ok {
+				return nil, errors.Errorf("unknown unit %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.Unit = u.Symbol
+			})
+
+		case "bools":
+			mode, ok := boolStringsNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown bools mode %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.BoolStrings = mode
+			})
+
+		case "empty":
+			mode, ok := emptyStringNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown empty mode %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.EmptyString = mode
+			})
+
+		case "numeric":
+			mode, ok := numericStringsNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown numeric mode %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.NumericStrings = mode
+			})
+
+		case "radix":
+			o.directives = append(o.directives, func(p *Policy) {
+				p.Radix = true
+			})
+
+		case "unwrap":
+			o.directives = append(o.directives, func(p *Policy) {
+				p.UnwrapArrays = true
+			})
+
+		case "trim":
+			o.directives = append(o.directives, func(p *Policy) {
+				p.Trim = true
+			})
+
+		case "notnull":
+			o.directives = append(o.directives, func(p *Policy) {
+				p.RejectNull = true
+			})
+
+		case "alias":
+			o.aliases = append(o.aliases, strings.Split(value, "|")...)
+
+		case "keys":
+			mode, ok := keyMatchNames[value]
+			if !ok {
+				return nil, errors.Errorf("unknown keys mode %q", value)
+			}
+			o.directives = append(o.directives, func(p *Policy) {
+				p.KeyMatch = mode
+			})
+
+		case "default":
+			o.def = json.RawMessage(value)
+			if kind := kindOf(o.def); !json.Valid(o.def) ||
+				kind == KindObject || kind == KindArray {
+				b, err := json.Marshal(value)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				o.def = b
+			}
+		}
+	}
+	return o, nil
+}
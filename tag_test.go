@@ -0,0 +1,116 @@
+package ft_test
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestTagDirectives(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		ID       ft.String  `json:"id" ft:"strict"`
+		Name     ft.NString `json:"name" ft:"trim"`
+		Qty      ft.Int     `json:"qty" ft:"trim,round=reject"`
+		Flag     ft.Bool    `json:"flag" ft:"kinds=bool|null"`
+		Required ft.NInt    `json:"required" ft:"notnull"`
+		Count    ft.Int     `json:"count" ft:"default=10"`
+		Label    ft.String  `json:"label" ft:"default=none"`
+		Price    *ft.Float  `json:"price" ft:"default=1.5"`
+		Plain    ft.Int     `json:"plain"`
+	}
+
+	d := Data{}
+	err := ft.Unmarshal([]byte(`{
+		"id": "123",
+		"name": "  foo ",
+		"qty": " 3 ",
+		"flag": true,
+		"required": 1,
+		"label": null
+	}`), &d, ft.Policy{})
+	is.NoErr(err)
+	is.Equal("123", d.ID.String)            // Value must match
+	is.Equal("foo", d.Name.String)          // Must be trimmed
+	is.Equal(int64(3), d.Qty.Int64)         // Must be trimmed
+	is.Equal(true, d.Flag.Bool)             // Value must match
+	is.Equal(int64(10), d.Count.Int64)      // Default for missing key
+	is.Equal("none", d.Label.String)        // Default for null
+	is.Equal(float64(1.5), d.Price.Float64) // Default for pointer
+
+	d = Data{}
+	err = ft.Unmarshal([]byte(`{"id": 123}`), &d, ft.Policy{})
+	is.Equal("value is a number", err.Error())
+
+	err = ft.Unmarshal([]byte(`{"qty": 2.5}`), &d, ft.Policy{})
+	is.Equal("value 2.5 has a fractional part", err.Error())
+
+	err = ft.Unmarshal([]byte(`{"flag": "true"}`), &d, ft.Policy{})
+	is.Equal("value is a string", err.Error())
+
+	err = ft.Unmarshal([]byte(`{"required": null}`), &d, ft.Policy{})
+	is.Equal("value is null", err.Error())
+
+	// Directives override the decoder policy for the field only
+	p := ft.Policy{IntKinds: ft.KindNumber}
+	err = ft.Unmarshal([]byte(`{"qty": " 4"}`), &d, p)
+	is.Equal("value is a string", err.Error())
+	err = ft.Unmarshal([]byte(`{"plain": "4"}`), &d, p)
+	is.Equal("value is a string", err.Error())
+	err = ft.Unmarshal([]byte(`{"plain": 4, "name": " x "}`), &d, p)
+	is.NoErr(err)
+	is.Equal("x", d.Name.String) // Must be trimmed
+}
+
+func TestTagDirectivesNested(t *testing.T) {
+	is := is.New(t)
+
+	type Item struct {
+		Qty ft.Int `json:"qty"`
+	}
+	type Data struct {
+		Items []Item `json:"items" ft:"strict"`
+	}
+
+	d := Data{}
+	err := ft.Unmarshal([]byte(`{"items": [{"qty": 1}, {"qty": "2"}]}`),
+		&d, ft.Policy{})
+	is.Equal("value is a string", err.Error())
+}
+
+func TestTagDirectivesInvalid(t *testing.T) {
+	is := is.New(t)
+
+	type Unknown struct {
+		ID ft.String `json:"id" ft:"strict,bogus"`
+	}
+	err := ft.Unmarshal([]byte(`{}`), &Unknown{}, ft.Policy{})
+	is.Equal(`ft: invalid tag on field ft_test.Unknown.ID: unknown directive "bogus"`,
+		err.Error())
+	// Fails on first use, not when the key is present
+	err = ft.Unmarshal([]byte(`{}`), &Unknown{}, ft.Policy{})
+	is.True(err != nil)
+
+	type Round struct {
+		Qty ft.Int `ft:"round=up"`
+	}
+	err = ft.Unmarshal([]byte(`{}`), &Round{}, ft.Policy{})
+	is.Equal(`ft: invalid tag on field ft_test.Round.Qty: unknown round mode "up"`,
+		err.Error())
+
+	type Kinds struct {
+		Qty ft.Int `ft:"kinds=number|text"`
+	}
+	err = ft.Unmarshal([]byte(`{}`), &Kinds{}, ft.Policy{})
+	is.Equal(`ft: invalid tag on field ft_test.Kinds.Qty: unknown kind "text"`,
+		err.Error())
+
+	type Value struct {
+		Qty ft.Int `ft:"trim=true"`
+	}
+	err = ft.Unmarshal([]byte(`{}`), &Value{}, ft.Policy{})
+	is.Equal(`ft: invalid tag on field ft_test.Value.Qty: directive "trim" does not take a value`,
+		err.Error())
+}
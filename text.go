@@ -0,0 +1,32 @@
+package ft
+
+// Text, e.g. map keys, flag values and XML attributes, is decoded by the
+// UnmarshalText methods like the content of a JSON string. So the text
+// foo and the JSON value "foo" decode to the same value, and text is
+// never parsed as a JSON literal, e.g. the text null is the string "null".
+// The same coercion rules apply, except that kinds do not apply to text,
+// e.g. with the strict profile ft.Int decodes the text 123.
+//
+// Empty text is decoded as per Policy.EmptyText, by default N-types are
+// null, and the MarshalText methods of N-types return empty text for null
+
+// textPolicyUnmarshaler is implemented by types that coerce text
+// as per a Policy. UnmarshalText uses the default policy
+type textPolicyUnmarshaler interface {
+	unmarshalText(text []byte, p *Policy) error
+}
+
+// text returns text as a JSON string, and the policy to coerce it with
+func (p *Policy) text(text []byte, nullable bool) ([]byte, *Policy) {
+	tp := *p
+	tp.StringKinds, tp.IntKinds, tp.FloatKinds, tp.BoolKinds = 0, 0, 0, 0
+	if len(text) == 0 {
+		switch {
+		case p.EmptyText != EmptyStringKeep:
+			tp.EmptyString = p.EmptyText
+		case nullable:
+			tp.EmptyString = EmptyStringNull
+		}
+	}
+	return QuoteJSON(string(text), 0), &tp
+}
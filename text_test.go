@@ -0,0 +1,133 @@
+package ft_test
+
+import (
+	"encoding"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestUnmarshalText(t *testing.T) {
+	is := is.New(t)
+
+	// Text is decoded like the content of a JSON string
+	s := ft.String{}
+	is.NoErr(s.UnmarshalText([]byte(`foo`)))
+	is.Equal("foo", s.String) // Value must match
+	is.NoErr(s.UnmarshalText([]byte(`"foo"`)))
+	is.Equal(`"foo"`, s.String) // Quotes must be kept
+	is.NoErr(s.UnmarshalText([]byte(`null`)))
+	is.Equal("null", s.String) // Value must match
+	is.NoErr(s.UnmarshalText([]byte(`a\u00e9`)))
+	is.Equal(`a\u00e9`, s.String) // Escapes must be kept
+
+	i := ft.Int{}
+	is.NoErr(i.UnmarshalText([]byte(`123`)))
+	is.Equal(int64(123), i.Int64) // Value must match
+	is.NoErr(i.UnmarshalText([]byte(`2.9`)))
+	is.Equal(int64(2), i.Int64) // Value must match
+	err := i.UnmarshalText([]byte(`abc`))
+	is.True(err != nil) // Must not be a number
+	err = i.UnmarshalText([]byte(``))
+	is.True(err != nil) // Empty text is not a number
+
+	f := ft.Float{}
+	is.NoErr(f.UnmarshalText([]byte(`1.618`)))
+	is.Equal(1.618, f.Float64) // Value must match
+	is.NoErr(f.UnmarshalText([]byte(`-Infinity`)))
+	txt, err := f.MarshalText()
+	is.NoErr(err)
+	is.Equal("-Infinity", string(txt)) // Text must round trip
+
+	b := ft.Bool{}
+	is.NoErr(b.UnmarshalText([]byte(`yes`)))
+	is.Equal(true, b.Bool) // Value must match
+	is.NoErr(b.UnmarshalText([]byte(``)))
+	is.Equal(false, b.Bool) // Value must match
+
+	// Empty text is null for N-types
+	for _, u := range []interface {
+		encoding.TextUnmarshaler
+		encoding.TextMarshaler
+	}{
+		&ft.NString{}, &ft.NInt{}, &ft.NFloat{}, &ft.NBool{}, &ft.NSecret{},
+		&ft.NVersion{}, &ft.NCardNumber{},
+	} {
+		is.NoErr(u.UnmarshalText([]byte(``)))
+		txt, err := u.MarshalText()
+		is.NoErr(err)
+		is.Equal("", string(txt)) // Null must be empty text
+	}
+	ns := ft.NStringFrom("foo")
+	is.NoErr(ns.UnmarshalText([]byte(``)))
+	is.Equal(false, ns.Valid) // Must be null
+	ni := ft.NIntFrom(1)
+	is.NoErr(ni.UnmarshalText([]byte(`42`)))
+	is.Equal(ft.NIntFrom(42), ni) // Value must match
+}
+
+func TestDecodeText(t *testing.T) {
+	is := is.New(t)
+
+	// Map keys are coerced as per the policy
+	m := map[ft.Int]bool{}
+	err := ft.Unmarshal([]byte(`{"0x1F": true}`), &m, ft.Policy{Radix: true})
+	is.NoErr(err)
+	is.True(m[ft.IntFrom(31)]) // Key must match
+
+	// Kinds do not apply to text
+	m = map[ft.Int]bool{}
+	err = ft.Unmarshal([]byte(`{"123": true}`), &m, ft.ProfileStrict())
+	is.NoErr(err)
+	is.True(m[ft.IntFrom(123)]) // Key must match
+
+	// EmptyText
+	nm := map[ft.NInt]bool{}
+	err = ft.Unmarshal([]byte(`{"": true}`), &nm, ft.Policy{})
+	is.NoErr(err)
+	is.True(nm[ft.NInt{}]) // Key must be null
+	nm = map[ft.NInt]bool{}
+	err = ft.Unmarshal([]byte(`{"": true}`), &nm,
+		ft.Policy{EmptyText: ft.EmptyStringZero})
+	is.NoErr(err)
+	is.True(nm[ft.NIntFrom(0)]) // Key must be zero
+	err = ft.Unmarshal([]byte(`{"": true}`), &nm,
+		ft.Policy{EmptyText: ft.EmptyStringError})
+	is.Equal("value is an empty string", err.Error())
+
+	sm := map[ft.NString]bool{}
+	err = ft.Unmarshal([]byte(`{"": true}`), &sm,
+		ft.Policy{EmptyText: ft.EmptyStringKeep})
+	is.NoErr(err)
+	is.True(sm[ft.NString{}]) // Key must be null by default
+
+	type Data struct {
+		Versions map[ft.NVersion]bool    `json:"versions"`
+		Cards    map[ft.NCardNumber]bool `json:"cards"`
+	}
+	d := Data{}
+	err = ft.Unmarshal([]byte(`{"versions": {"": true, "1.2.3": true}}`),
+		&d, ft.Policy{})
+	is.NoErr(err)
+	is.True(d.Versions[ft.NVersion{}]) // Key must be null
+	is.True(d.Versions[ft.NVersionFrom(ft.VersionFrom(1, 2, 3))])
+	d = Data{}
+	err = ft.Unmarshal([]byte(`{"versions": {"": true}, "cards": {"": true}}`),
+		&d, ft.Policy{EmptyText: ft.EmptyStringZero})
+	is.NoErr(err)
+	is.True(d.Versions[ft.NVersion{Valid: true}]) // Key must be zero
+	is.True(d.Cards[ft.NCardNumber{Valid: true}]) // Key must be zero
+	for _, input := range []string{
+		`{"versions": {"": true}}`, `{"cards": {"": true}}`,
+	} {
+		err = ft.Unmarshal([]byte(input), &Data{},
+			ft.Policy{EmptyText: ft.EmptyStringError})
+		is.Equal("value is an empty string", err.Error())
+	}
+	d = Data{}
+	err = ft.Unmarshal([]byte(`{"versions": {"n/a": true}}`), &d,
+		ft.Policy{NullStrings: []string{"N/A"}})
+	is.NoErr(err)
+	is.True(d.Versions[ft.NVersion{}]) // Key must be null
+}
@@ -0,0 +1,311 @@
+package ft
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Version can be used to decode a semantic version from a JSON string or
+// number. Loose input like "v1.2", 1.2 and 2 is normalised to full semver,
+// i.e. "1.2.0" and "2.0.0". Pre-release and build metadata is preserved,
+// see https://semver.org
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	PreRelease string
+	Build      string
+}
+
+func VersionFrom(major, minor, patch uint64) Version {
+	return Version{Major: major, Minor: minor, Patch: patch}
+}
+
+// ParseVersion returns a Version for the given string.
+// The "v" prefix is optional, and minor and patch default to zero
+func ParseVersion(s string) (v Version, err error) {
+	in := s
+	s = strings.TrimSpace(s)
+	if len(s) > 0 && (s[0] == 'v' || s[0] == 'V') {
+		s = s[1:]
+	}
+
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		v.Build = s[i+1:]
+		s = s[:i]
+		if err = validateIdentifiers(v.Build, false); err != nil {
+			return Version{}, errors.Errorf(
+				"invalid version %q: build %s", in, err)
+		}
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.PreRelease = s[i+1:]
+		s = s[:i]
+		if err = validateIdentifiers(v.PreRelease, true); err != nil {
+			return Version{}, errors.Errorf(
+				"invalid version %q: pre-release %s", in, err)
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, errors.Errorf(
+			"invalid version %q: too many components", in)
+	}
+	nums := [3]uint64{}
+	for i, part := range parts {
+		if !isNumericIdentifier(part) {
+			return Version{}, errors.Errorf(
+				"invalid version %q: %q is not a valid number", in, part)
+		}
+		nums[i], err = strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return Version{}, errors.Errorf(
+				"invalid version %q: %q is out of range", in, part)
+		}
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	return v, nil
+}
+
+// isNumericIdentifier returns true for a non-empty string of digits
+// without leading zeros
+func isNumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s == "0" || s[0] != '0'
+}
+
+// validateIdentifiers checks dot-separated pre-release or build identifiers.
+// Numeric pre-release identifiers must not have leading zeros
+func validateIdentifiers(s string, preRelease bool) error {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return errors.Errorf("has an empty identifier")
+		}
+		numeric := true
+		for _, r := range id {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				numeric = false
+			default:
+				return errors.Errorf("identifier %q has invalid characters", id)
+			}
+		}
+		if preRelease && numeric && !isNumericIdentifier(id) {
+			return errors.Errorf("identifier %q has leading zeros", id)
+		}
+	}
+	return nil
+}
+
+// String returns the canonical "MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]" form
+func (fv Version) String() string {
+	s := strconv.FormatUint(fv.Major, 10) + "." +
+		strconv.FormatUint(fv.Minor, 10) + "." +
+		strconv.FormatUint(fv.Patch, 10)
+	if fv.PreRelease != "" {
+		s += "-" + fv.PreRelease
+	}
+	if fv.Build != "" {
+		s += "+" + fv.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if fv has lower, equal or higher precedence
+// than other. Build metadata is ignored as per the semver spec
+func (fv Version) Compare(other Version) int {
+	if c := compareUint(fv.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(fv.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(fv.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	// A version without pre-release has higher precedence
+	if fv.PreRelease == "" || other.PreRelease == "" {
+		switch {
+		case fv.PreRelease == other.PreRelease:
+			return 0
+		case fv.PreRelease == "":
+			return 1
+		}
+		return -1
+	}
+
+	a := strings.Split(fv.PreRelease, ".")
+	b := strings.Split(other.PreRelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
+
+// Less returns true if fv has lower precedence than other
+func (fv Version) Less(other Version) bool {
+	return fv.Compare(other) < 0
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifier compares pre-release identifiers, numeric identifiers
+// have lower precedence than alphanumeric ones. Numeric identifiers have
+// no leading zeros, so they are compared by length and then lexically,
+// they may be larger than uint64
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumericIdentifier(a), isNumericIdentifier(b)
+	switch {
+	case aNum && bNum:
+		if c := compareUint(uint64(len(a)), uint64(len(b))); c != 0 {
+			return c
+		}
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// MarshalJSON method for Version
+func (fv Version) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fv.String())
+}
+
+// UnmarshalJSON method for Version
+func (fv *Version) UnmarshalJSON(bArr []byte) (err error) {
+	s := ""
+
+	// Value is null
+	if string(bArr) == "null" {
+		*fv = Version{}
+		return
+	}
+
+	// Value is a...
+	// string
+	if err = json.Unmarshal(bArr, &s); err == nil {
+		v, err := ParseVersion(s)
+		if err != nil {
+			return err
+		}
+		*fv = v
+		return nil
+	}
+
+	// number, use the raw bytes so 1.10 is not parsed as 1.1
+	if isJSONNumber(bArr) {
+		v, err := ParseVersion(string(bytes.TrimSpace(bArr)))
+		if err != nil {
+			return err
+		}
+		*fv = v
+		return nil
+	}
+
+	return errors.Errorf("invalid version %s", bArr)
+}
+
+// isJSONNumber returns true if b is a valid JSON number
+func isJSONNumber(b []byte) bool {
+	var n json.Number
+	return json.Unmarshal(b, &n) == nil
+}
+
+func (fv Version) MarshalText() (text []byte, err error) {
+	return []byte(fv.String()), nil
+}
+
+func (fv *Version) UnmarshalText(text []byte) error {
+	v, err := ParseVersion(string(text))
+	if err != nil {
+		return err
+	}
+	*fv = v
+	return nil
+}
+
+// NVersion can be used to decode a semantic version that allows null,
+// see Version
+type NVersion struct {
+	Version
+	Valid bool
+}
+
+func NVersionFrom(v Version) NVersion {
+	return NVersion{Version: v, Valid: true}
+}
+
+// MarshalJSON method for NVersion
+func (fv NVersion) MarshalJSON() ([]byte, error) {
+	if !fv.Valid {
+		return []byte(`null`), nil
+	}
+	return fv.Version.MarshalJSON()
+}
+
+// UnmarshalJSON method for NVersion
+func (fv *NVersion) UnmarshalJSON(bArr []byte) (err error) {
+	// Value is null
+	if string(bArr) == "null" {
+		*fv = NVersion{}
+		return
+	}
+
+	v := Version{}
+	if err = v.UnmarshalJSON(bArr); err != nil {
+		return err
+	}
+	*fv = NVersionFrom(v)
+	return
+}
+
+func (fv NVersion) MarshalText() (text []byte, err error) {
+	if !fv.Valid {
+		return []byte{}, nil
+	}
+	return fv.Version.MarshalText()
+}
+
+func (fv *NVersion) UnmarshalText(text []byte) error {
+	return fv.unmarshalText(text, &defaultPolicy)
+}
+
+func (fv *NVersion) unmarshalText(text []byte, p *Policy) error {
+	bArr, tp := p.text(text, true)
+	if ok, valid, err := tp.nullOrEmpty(
+		string(text), tp.StringKinds, defaultStringKinds, true); ok {
+		if err != nil {
+			return err
+		}
+		*fv = NVersion{Valid: valid}
+		return nil
+	}
+	return fv.UnmarshalJSON(bArr)
+}
@@ -0,0 +1,166 @@
+package ft_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/mozey/ft"
+)
+
+func TestUnmarshalVersion(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Version ft.Version `json:"version"`
+	}
+	d := Data{}
+
+	// null
+	b := []byte(`{"version": null}`)
+	err := json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Version{}, d.Version) // Value must match
+
+	// string
+	b = []byte(`{"version": "1.2.3"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("1.2.3", d.Version.String()) // Value must match
+
+	b = []byte(`{"version": "v1.2"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("1.2.0", d.Version.String()) // Value must match
+
+	b = []byte(`{"version": "1.0.0-rc.1+build.5"}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(ft.Version{
+		Major: 1, PreRelease: "rc.1", Build: "build.5"}, d.Version)
+
+	b = []byte(`{"version": "1.2.3.4"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal(`invalid version "1.2.3.4": too many components`, err.Error())
+
+	b = []byte(`{"version": "01.2.3"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal(`invalid version "01.2.3": "01" is not a valid number`,
+		err.Error())
+
+	b = []byte(`{"version": "1.2.3-01"}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal(`invalid version "1.2.3-01": pre-release identifier "01" has leading zeros`,
+		err.Error())
+
+	// int
+	b = []byte(`{"version": 2}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("2.0.0", d.Version.String()) // Value must match
+
+	// float, raw bytes are used so 1.10 is not 1.1
+	b = []byte(`{"version": 1.10}`)
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal("1.10.0", d.Version.String()) // Value must match
+
+	b = []byte(`{"version": 1e3}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal(`invalid version "1e3": "1e3" is not a valid number`,
+		err.Error())
+
+	// bool
+	b = []byte(`{"version": true}`)
+	err = json.Unmarshal(b, &d)
+	is.Equal("invalid version true", err.Error())
+}
+
+func TestCompareVersion(t *testing.T) {
+	is := is.New(t)
+
+	// Ordered as per the example in https://semver.org/#spec-item-11
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		a, err := ft.ParseVersion(ordered[i-1])
+		is.NoErr(err)
+		b, err := ft.ParseVersion(ordered[i])
+		is.NoErr(err)
+		is.True(a.Less(b))        // Must have lower precedence
+		is.Equal(1, b.Compare(a)) // Must have higher precedence
+		is.Equal(0, a.Compare(a)) // Must be equal to itself
+		is.True(!b.Less(a))       // Must not have lower precedence
+	}
+
+	// Numeric identifiers may be larger than uint64
+	ordered = []string{
+		"1.0.0-9",
+		"1.0.0-18446744073709551615",
+		"1.0.0-99999999999999999999",
+		"1.0.0-100000000000000000000",
+		"1.0.0-alpha",
+	}
+	for i := 1; i < len(ordered); i++ {
+		a, err := ft.ParseVersion(ordered[i-1])
+		is.NoErr(err)
+		b, err := ft.ParseVersion(ordered[i])
+		is.NoErr(err)
+		is.True(a.Less(b)) // Must have lower precedence
+	}
+
+	// Build metadata is ignored
+	a, _ := ft.ParseVersion("1.0.0+a")
+	b, _ := ft.ParseVersion("1.0.0+b")
+	is.Equal(0, a.Compare(b))
+}
+
+func TestMarshalVersion(t *testing.T) {
+	is := is.New(t)
+
+	type Data struct {
+		Version  ft.Version  `json:"version"`
+		NVersion ft.NVersion `json:"nversion"`
+	}
+
+	d := Data{Version: ft.VersionFrom(1, 2, 0)}
+	b, err := json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"version":"1.2.0","nversion":null}`, string(b))
+
+	b = []byte(`{"version":"v3","nversion":"1.0.0-beta+exp.sha.5114f85"}`)
+	d = Data{}
+	err = json.Unmarshal(b, &d)
+	is.NoErr(err)
+	is.Equal(true, d.NVersion.Valid) // Must be valid
+	b, err = json.Marshal(d)
+	is.NoErr(err)
+	is.Equal(`{"version":"3.0.0","nversion":"1.0.0-beta+exp.sha.5114f85"}`,
+		string(b))
+
+	// Map keys
+	m := map[ft.Version]bool{}
+	err = json.Unmarshal([]byte(`{"v1.2": true}`), &m)
+	is.NoErr(err)
+	is.Equal(true, m[ft.VersionFrom(1, 2, 0)]) // Key must match
+
+	// Null map keys are empty text
+	b, err = json.Marshal(map[ft.NVersion]bool{{}: true})
+	is.NoErr(err)
+	is.Equal(`{"":true}`, string(b))
+	nm := map[ft.NVersion]bool{}
+	err = json.Unmarshal(b, &nm)
+	is.NoErr(err)
+	is.True(nm[ft.NVersion{}]) // Key must be null
+}